@@ -0,0 +1,147 @@
+package jubjub
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidBech32 = errors.New("not a valid bech32 string")
+)
+
+// BIP 173 Bech32 and BIP 350 Bech32m, without the 90-character length limit.
+// Zcash encodes spending and viewing keys that are far longer than Bitcoin
+// addresses, so callers pass the longest valid encoding of each kind instead.
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// bech32Variant is the constant that a valid checksum's polymod must equal.
+type bech32Variant uint32
+
+const (
+	bech32  bech32Variant = 1
+	bech32m bech32Variant = 0x2bc830a3
+)
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := uint(0); i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	out := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// bech32Encode encodes 8-bit data under the human-readable part hrp.
+func bech32Encode(hrp string, data []byte, variant bech32Variant) string {
+	values := convertBits(data, 8, 5, true)
+
+	check := append(bech32HrpExpand(hrp), values...)
+	check = append(check, 0, 0, 0, 0, 0, 0)
+	polymod := bech32Polymod(check) ^ uint32(variant)
+
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, v := range values {
+		b.WriteByte(bech32Charset[v])
+	}
+	for i := uint(0); i < 6; i++ {
+		b.WriteByte(bech32Charset[(polymod>>(5*(5-i)))&31])
+	}
+	return b.String()
+}
+
+// bech32Decode validates a string of at most maxLength characters with the
+// given checksum variant and returns its human-readable part and 8-bit data.
+func bech32Decode(s string, variant bech32Variant, maxLength int) (string, []byte, error) {
+	if len(s) > maxLength {
+		return "", nil, ErrInvalidBech32
+	}
+
+	lower, upper := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 33 || c > 126 {
+			return "", nil, ErrInvalidBech32
+		}
+		lower = lower || (c >= 'a' && c <= 'z')
+		upper = upper || (c >= 'A' && c <= 'Z')
+	}
+	if lower && upper {
+		return "", nil, ErrInvalidBech32
+	}
+	s = strings.ToLower(s)
+
+	pos := strings.LastIndexByte(s, '1')
+	if pos < 1 || pos+7 > len(s) {
+		return "", nil, ErrInvalidBech32
+	}
+
+	hrp := s[:pos]
+	values := make([]byte, 0, len(s)-pos-1)
+	for i := pos + 1; i < len(s); i++ {
+		v := strings.IndexByte(bech32Charset, s[i])
+		if v < 0 {
+			return "", nil, ErrInvalidBech32
+		}
+		values = append(values, byte(v))
+	}
+
+	if bech32Polymod(append(bech32HrpExpand(hrp), values...)) != uint32(variant) {
+		return "", nil, ErrInvalidBech32
+	}
+
+	data := convertBits(values[:len(values)-6], 5, 8, false)
+	if data == nil {
+		return "", nil, ErrInvalidBech32
+	}
+	return hrp, data, nil
+}
+
+// convertBits regroups a sequence of fromBits-bit values into toBits-bit
+// values. Without padding, it returns nil if the input has leftover nonzero bits
+// or a whole leftover group.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) []byte {
+	var acc uint32
+	var bits uint
+	maxv := uint32(1)<<toBits - 1
+
+	out := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+	for _, v := range data {
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte((acc>>bits)&maxv))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			out = append(out, byte((acc<<(toBits-bits))&maxv))
+		}
+	} else if bits >= fromBits || (acc<<(toBits-bits))&maxv != 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+package jubjub
+
+import (
+	"encoding"
+	"encoding/binary"
+	"hash"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+)
+
+// Zcash uses the personalization field of the BLAKE2 parameter block for domain
+// separation, which golang.org/x/crypto doesn't expose. The personalization is
+// XORed into the last two words of the initial state, so we apply it by editing
+// the marshaled state of a fresh digest.
+
+// personalizedHash is a BLAKE2 digest that keeps its personalization across Reset.
+type personalizedHash struct {
+	hash.Hash
+	initial []byte
+}
+
+// Reset restores the personalized initial state.
+func (h *personalizedHash) Reset() {
+	h.Hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(h.initial)
+}
+
+// newBlake2s returns a BLAKE2s-256 hash personalized with the 8-byte string person.
+func newBlake2s(person string) hash.Hash {
+	if len(person) != 8 {
+		panic("jubjub: BLAKE2s personalization must be 8 bytes")
+	}
+
+	h, _ := blake2s.New256(nil)
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+
+	// Skip the 3-byte magic, then h[6] and h[7] are big-endian uint32s.
+	for i := 0; i < 2; i++ {
+		off := 3 + 4*(6+i)
+		word := binary.BigEndian.Uint32(state[off:])
+		word ^= binary.LittleEndian.Uint32([]byte(person[4*i:]))
+		binary.BigEndian.PutUint32(state[off:], word)
+	}
+
+	ph := &personalizedHash{h, state}
+	ph.Reset()
+	return ph
+}
+
+// newBlake2b returns a BLAKE2b hash with an output of size bytes, personalized with the 16-byte string person.
+func newBlake2b(size int, person string) hash.Hash {
+	if len(person) != 16 {
+		panic("jubjub: BLAKE2b personalization must be 16 bytes")
+	}
+
+	h, err := blake2b.New(size, nil)
+	if err != nil {
+		panic(err)
+	}
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+
+	// Skip the 3-byte magic, then h[6] and h[7] are big-endian uint64s.
+	for i := 0; i < 2; i++ {
+		off := 3 + 8*(6+i)
+		word := binary.BigEndian.Uint64(state[off:])
+		word ^= binary.LittleEndian.Uint64([]byte(person[8*i:]))
+		binary.BigEndian.PutUint64(state[off:], word)
+	}
+
+	ph := &personalizedHash{h, state}
+	ph.Reset()
+	return ph
+}
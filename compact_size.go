@@ -0,0 +1,67 @@
+package jubjub
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidCompactSize = errors.New("not a valid CompactSize")
+)
+
+// maxCompactSize is the largest value zcashd accepts in a CompactSize.
+const maxCompactSize = 0x02000000
+
+// appendCompactSize appends the Bitcoin CompactSize encoding of n.
+func appendCompactSize(out []byte, n uint64) []byte {
+	var buf [9]byte
+	switch {
+	case n < 0xfd:
+		return append(out, byte(n))
+	case n <= 0xffff:
+		buf[0] = 0xfd
+		binary.LittleEndian.PutUint16(buf[1:], uint16(n))
+		return append(out, buf[:3]...)
+	case n <= 0xffffffff:
+		buf[0] = 0xfe
+		binary.LittleEndian.PutUint32(buf[1:], uint32(n))
+		return append(out, buf[:5]...)
+	default:
+		buf[0] = 0xff
+		binary.LittleEndian.PutUint64(buf[1:], n)
+		return append(out, buf[:]...)
+	}
+}
+
+// readCompactSize reads a canonical CompactSize no larger than maxCompactSize
+// and returns it with the remaining input.
+func readCompactSize(in []byte) (uint64, []byte, error) {
+	if len(in) == 0 {
+		return 0, nil, ErrInvalidCompactSize
+	}
+
+	var n, min uint64
+	var size int
+	switch in[0] {
+	case 0xfd:
+		size, min = 2, 0xfd
+	case 0xfe:
+		size, min = 4, 0x10000
+	case 0xff:
+		size, min = 8, 0x100000000
+	default:
+		return uint64(in[0]), in[1:], nil
+	}
+
+	if len(in) < 1+size {
+		return 0, nil, ErrInvalidCompactSize
+	}
+	for i := size; i > 0; i-- {
+		n = n<<8 | uint64(in[i])
+	}
+	if n < min || n > maxCompactSize {
+		return 0, nil, ErrInvalidCompactSize
+	}
+	return n, in[1+size:], nil
+}
@@ -0,0 +1,177 @@
+package jubjub
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidCurveParams = errors.New("not a valid twisted Edwards parameter set")
+)
+
+// CurveParams describes a twisted Edwards curve a*x^2 + y^2 = 1 + d*x^2*y^2
+// over the field of order P, whose points form a group of order Cofactor*R
+// for a prime R.
+type CurveParams struct {
+	Name string
+
+	P        *big.Int // order of the base field
+	R        *big.Int // order of the prime-order subgroup
+	A, D     *big.Int // curve coefficients
+	Cofactor *big.Int
+
+	// Gx, Gy are the coordinates of the generator returned by Generator.
+	Gx, Gy *big.Int
+}
+
+func mustParseInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("jubjub: bad curve constant " + s)
+	}
+	return n
+}
+
+// JubjubParams returns the parameters of Jubjub, the curve used by Sapling,
+// whose base field is the scalar field of BLS12-381. The generator is the
+// positive point with y = 11, which generates the full group.
+func JubjubParams() *CurveParams {
+	p := mustParseInt("52435875175126190479447740508185965837690552500527637822603658699938581184513")
+
+	// d = -10240/10241
+	d := new(big.Int).ModInverse(big.NewInt(10241), p)
+	d.Mul(d, big.NewInt(-10240)).Mod(d, p)
+
+	return &CurveParams{
+		Name:     "Jubjub",
+		P:        p,
+		R:        mustParseInt("6554484396890773809930967563523245729705921265872317281365359162392183254199"),
+		A:        big.NewInt(-1),
+		D:        d,
+		Cofactor: big.NewInt(8),
+		Gx:       mustParseInt("44746807950788659978687200207992930935149218647843500701850233404325651525118"),
+		Gy:       big.NewInt(11),
+	}
+}
+
+// EdBLS12377Params returns the parameters of ed-on-bls12-377, whose base field
+// is the scalar field of BLS12-377. The generator is the prime-order one used
+// by arkworks and gnark-crypto.
+func EdBLS12377Params() *CurveParams {
+	return &CurveParams{
+		Name:     "ed-on-bls12-377",
+		P:        mustParseInt("8444461749428370424248824938781546531375899335154063827935233455917409239041"),
+		R:        mustParseInt("2111115437357092606062206234695386632838870926408408195193685246394721360383"),
+		A:        big.NewInt(-1),
+		D:        big.NewInt(3021),
+		Cofactor: big.NewInt(4),
+		Gx:       mustParseInt("717051916204163000937139483451426116831771857428389560441264442629694842243"),
+		Gy:       mustParseInt("882565546457454111605105352482086902132191855952243170543452705048019814192"),
+	}
+}
+
+// BabyJubjubParams returns the parameters of Baby Jubjub from EIP-2494, whose
+// base field is the scalar field of BN254. The generator is the prime-order
+// base point B8 used by circomlib and iden3.
+func BabyJubjubParams() *CurveParams {
+	return &CurveParams{
+		Name:     "BabyJubjub",
+		P:        mustParseInt("21888242871839275222246405745257275088548364400416034343698204186575808495617"),
+		R:        mustParseInt("2736030358979909402780800718157159386076813972158567259200215660948447373041"),
+		A:        big.NewInt(168700),
+		D:        big.NewInt(168696),
+		Cofactor: big.NewInt(8),
+		Gx:       mustParseInt("5299619240641551281634865583518297030282874472190772894086521144482721001553"),
+		Gy:       mustParseInt("16950150798460657717958625567821834550301663161624707787222815936182638968203"),
+	}
+}
+
+// BandersnatchParams returns the parameters of Bandersnatch in twisted Edwards
+// form, whose base field is the scalar field of BLS12-381. The generator is the
+// prime-order one used by arkworks and gnark-crypto.
+func BandersnatchParams() *CurveParams {
+	return &CurveParams{
+		Name:     "Bandersnatch",
+		P:        mustParseInt("52435875175126190479447740508185965837690552500527637822603658699938581184513"),
+		R:        mustParseInt("13108968793781547619861935127046491459309155893440570251786403306729687672801"),
+		A:        big.NewInt(-5),
+		D:        mustParseInt("45022363124591815672509500913686876175488063829319466900776701791074614335719"),
+		Cofactor: big.NewInt(4),
+		Gx:       mustParseInt("18886178867200960497001835917649091219057080094937609519140440539760939937304"),
+		Gy:       mustParseInt("19188667384257783945677642223292697773471335439753913231509108946878080696678"),
+	}
+}
+
+// NewCurve returns a context for the curve described by params. It checks that
+// the field and subgroup orders are prime, that elements of the field fit the
+// 32-byte compressed encoding with a spare sign bit, that the curve is
+// nonsingular, and that the generator is a point of order dividing Cofactor*R.
+//
+// The affine addition law is complete when a is a square and d is not, as on
+// Jubjub and Baby Jubjub. Otherwise, as on Bandersnatch, it is only guaranteed
+// to be complete on the prime-order subgroup, and Add and Double return the
+// invalid point (0, 0) where it is undefined.
+func NewCurve(params *CurveParams) (*Jubjub, error) {
+	if params.P == nil || params.R == nil || params.A == nil || params.D == nil ||
+		params.Cofactor == nil || params.Gx == nil || params.Gy == nil {
+		return nil, ErrInvalidCurveParams
+	}
+	p := params.P
+	if p.BitLen() > 255 || p.BitLen() <= 248 || !p.ProbablyPrime(20) || !params.R.ProbablyPrime(20) {
+		return nil, ErrInvalidCurveParams
+	}
+	if params.Cofactor.Sign() <= 0 || params.Cofactor.Cmp(params.R) >= 0 {
+		return nil, ErrInvalidCurveParams
+	}
+
+	a := new(big.Int).Mod(params.A, p)
+	d := new(big.Int).Mod(params.D, p)
+	if a.Sign() == 0 || d.Sign() == 0 || d.Cmp(a) == 0 {
+		return nil, ErrInvalidCurveParams
+	}
+
+	curve := newCurve(params)
+	g := curve.Generator()
+	if !g.IsOnCurve() || g.IsIdentity() {
+		return nil, ErrInvalidCurveParams
+	}
+	order := new(big.Int).Mul(params.R, params.Cofactor)
+	if !curve.ladder(order, order.BitLen(), g).IsIdentity() {
+		return nil, ErrInvalidCurveParams
+	}
+
+	return curve, nil
+}
+
+// newCurve builds the context for params without validating them.
+func newCurve(params *CurveParams) *Jubjub {
+	p := params.P
+	h, _ := newScalar(new(big.Int).Set(params.Cofactor), params.R)
+	return &Jubjub{
+		name:          params.Name,
+		fieldOrder:    new(big.Int).Set(p),
+		subgroupOrder: new(big.Int).Set(params.R),
+		generatorX:    newFieldElement(new(big.Int).Mod(params.Gx, p), p),
+		generatorY:    newFieldElement(new(big.Int).Mod(params.Gy, p), p),
+		a:             newFieldElement(new(big.Int).Mod(params.A, p), p),
+		d:             newFieldElement(new(big.Int).Mod(params.D, p), p),
+		cofactor:      h,
+		fieldZero:     newFieldElement(big.NewInt(0), p),
+		fieldOne:      newFieldElement(big.NewInt(1), p),
+	}
+}
+
+// Params returns a copy of the parameters the curve was created with.
+func (curve *Jubjub) Params() *CurveParams {
+	return &CurveParams{
+		Name:     curve.name,
+		P:        new(big.Int).Set(curve.fieldOrder),
+		R:        new(big.Int).Set(curve.subgroupOrder),
+		A:        new(big.Int).Set(curve.a.n),
+		D:        new(big.Int).Set(curve.d.n),
+		Cofactor: new(big.Int).Set(curve.cofactor.n),
+		Gx:       new(big.Int).Set(curve.generatorX.n),
+		Gy:       new(big.Int).Set(curve.generatorY.n),
+	}
+}
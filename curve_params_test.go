@@ -0,0 +1,214 @@
+package jubjub
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestNewCurve(t *testing.T) {
+	for _, params := range []*CurveParams{JubjubParams(), EdBLS12377Params(), BabyJubjubParams(), BandersnatchParams()} {
+		curve, err := NewCurve(params)
+		if err != nil {
+			t.Fatalf("%s: %v", params.Name, err)
+		}
+
+		g := curve.SubgroupGenerator()
+		if g.IsIdentity() || !g.inPrimeSubgroup() {
+			t.Errorf("%s: subgroup generator has the wrong order", params.Name)
+		}
+
+		p, err := curve.Decompress(g.Compress())
+		if err != nil {
+			t.Fatalf("%s: %v", params.Name, err)
+		}
+		if !p.Equals(g) {
+			t.Errorf("%s: generator didn't roundtrip", params.Name)
+		}
+
+		got := curve.Params()
+		if got.Name != params.Name || got.D.Cmp(new(big.Int).Mod(params.D, params.P)) != 0 || got.Gx.Cmp(params.Gx) != 0 {
+			t.Errorf("%s: parameters didn't roundtrip", params.Name)
+		}
+	}
+
+	jubjub, _ := NewCurve(JubjubParams())
+	if !bytes.Equal(jubjub.Generator().Compress(), Curve().Generator().Compress()) {
+		t.Error("NewCurve(JubjubParams()) differs from Curve()")
+	}
+}
+
+func TestNewCurveRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*CurveParams)
+		err    error
+	}{
+		{"a = d", func(p *CurveParams) { p.A = new(big.Int).Set(p.D) }, ErrInvalidCurveParams},
+		{"zero a", func(p *CurveParams) { p.A = big.NewInt(0) }, ErrInvalidCurveParams},
+		{"composite field", func(p *CurveParams) { p.P = new(big.Int).Add(p.P, big.NewInt(2)) }, ErrInvalidCurveParams},
+		{"off-curve generator", func(p *CurveParams) { p.Gy = big.NewInt(12) }, ErrInvalidCurveParams},
+		{"wrong cofactor", func(p *CurveParams) { p.Cofactor = big.NewInt(4) }, ErrInvalidCurveParams},
+		{"missing order", func(p *CurveParams) { p.R = nil }, ErrInvalidCurveParams},
+	}
+
+	for _, tt := range tests {
+		params := JubjubParams()
+		tt.modify(params)
+		if _, err := NewCurve(params); err != tt.err {
+			t.Errorf("%s: want %v, have %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func testPoint(t *testing.T, curve *Jubjub, x, y string) *Point {
+	p := &Point{curve, curve.newFieldElement(mustParseInt(x)), curve.newFieldElement(mustParseInt(y))}
+	if !p.IsOnCurve() {
+		t.Fatalf("%s: test point is not on the curve", curve.name)
+	}
+	return p
+}
+
+func TestBabyJubjubArithmetic(t *testing.T) {
+	curve, err := NewCurve(BabyJubjubParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// https://github.com/iden3/go-iden3-crypto/blob/master/babyjub/babyjub_test.go
+	a := testPoint(t, curve,
+		"17777552123799933955779906779655732241715742912184938656739573121738514868268",
+		"2626589144620713026669568689430873010625803728049924121243784502389097019475")
+	b := testPoint(t, curve,
+		"16540640123574156134436876038791482806971768689494387082833631921987005038935",
+		"20819045374670962167435360035096875258406992893633759881276124905556507972311")
+	a2 := testPoint(t, curve,
+		"6890855772600357754907169075114257697580319025794532037257385534741338397365",
+		"4338620300185947561074059802482547481416142213883829469920100239455078257889")
+	ab := testPoint(t, curve,
+		"7916061937171219682591368294088513039687205273691143098332585753343424131937",
+		"14035240266687799601661095864649209771790948434046947201833777492504781204499")
+	a3 := testPoint(t, curve,
+		"19372461775513343691590086534037741906533799473648040012278229434133483800898",
+		"9458658722007214007257525444427903161243386465067105737478306991484593958249")
+	ks := testPoint(t, curve,
+		"17070357974431721403481313912716834497662307308519659060910483826664480189605",
+		"4014745322800118607127020275658861516666525056516280575712425373174125159339")
+
+	if !curve.Double(a).Equals(a2) || !curve.Add(a, a).Equals(a2) {
+		t.Error("Incorrect doubling")
+	}
+	if !curve.Add(a, b).Equals(ab) || !newPoint(curve).Add(b, a).Equals(ab) {
+		t.Error("Incorrect addition")
+	}
+	if !newPoint(curve).Double(a).Add(a2, a).Equals(a3) {
+		t.Error("Incorrect tripling")
+	}
+
+	// The points aren't in the prime-order subgroup, so use the full scalar.
+	k := mustParseInt("14035240266687799601661095864649209771790948434046947201833777492504781204499")
+	if !curve.ladder(k, k.BitLen(), a).Equals(ks) {
+		t.Error("Incorrect scalar multiplication")
+	}
+
+	p, err := curve.Decompress(ab.Compress())
+	if err != nil || !p.Equals(ab) {
+		t.Error("Point didn't roundtrip")
+	}
+}
+
+func TestBandersnatchEndomorphism(t *testing.T) {
+	curve, err := NewCurve(BandersnatchParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := curve.Generator()
+
+	// The GLV endomorphism from gnark-crypto maps (x, y) to
+	//  (e1*(1 - y^2)*(y^2 - e0), e0*(y^2 + e0)*x*y) / ((y^2 - e0)*x*y)
+	// and acts on the prime-order subgroup as multiplication by lambda.
+	e0 := curve.newFieldElement(mustParseInt("37446463827641770816307242315180085052603635617490163568005256780843403514036"))
+	e1 := curve.newFieldElement(mustParseInt("49199877423542878313146170939139662862850515542392585932876811575731455068989"))
+	lambda := mustParseInt("8913659658109529928382530854484400854125314752504019737736543920008458395397")
+
+	yy := curve.newFieldElement(nil).Mul(g.y, g.y)
+	xy := curve.newFieldElement(nil).Mul(g.x, g.y)
+	f := curve.newFieldElement(nil).Sub(curve.fieldOne, yy)
+	f.Mul(f, e1)
+	h := curve.newFieldElement(nil).Sub(yy, e0)
+	z := curve.newFieldElement(nil).Mul(h, xy)
+	z.ModInverse(z)
+
+	phi := &Point{curve, curve.newFieldElement(nil), curve.newFieldElement(nil)}
+	phi.x.Mul(f, h).Mul(phi.x, z)
+	phi.y.Add(yy, e0).Mul(phi.y, e0).Mul(phi.y, xy).Mul(phi.y, z)
+
+	if !phi.IsOnCurve() {
+		t.Fatal("Endomorphism image is not on the curve")
+	}
+	sc, _ := curve.ScalarFromBig(lambda)
+	lg, _ := curve.ScalarMult(sc, g)
+	if !lg.Equals(phi) {
+		t.Error("[lambda]G doesn't match the endomorphism")
+	}
+	if !curve.Add(lg, g).Equals(newPoint(curve).Add(g, lg)) {
+		t.Error("Addition isn't commutative")
+	}
+}
+
+func TestEdBLS12377(t *testing.T) {
+	curve, err := NewCurve(EdBLS12377Params())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The generator of ark-ed-on-bls12-377, which differs from the one in
+	// EdBLS12377Params but must also have prime order.
+	// https://github.com/arkworks-rs/algebra/blob/master/curves/ed_on_bls12_377/src/curves/mod.rs
+	g := testPoint(t, curve,
+		"4497879464030519973909970603271755437257548612157028181994697785683032656389",
+		"4357141146396347889246900916607623952598927460421559113092863576544024487809")
+	if g.IsIdentity() || !g.inPrimeSubgroup() {
+		t.Error("arkworks generator has the wrong order")
+	}
+
+	three, _ := curve.ScalarFromBig(big.NewInt(3))
+	g3, err := curve.ScalarMult(three, g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !curve.Add(curve.Double(g), g).Equals(g3) {
+		t.Error("Addition and scalar multiplication disagree")
+	}
+
+	p, err := curve.Decompress(g3.Compress())
+	if err != nil || !p.Equals(g3) {
+		t.Error("Point didn't roundtrip")
+	}
+}
+
+func TestBandersnatchIncompleteAddition(t *testing.T) {
+	curve, err := NewCurve(BandersnatchParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := curve.Generator()
+
+	// A point outside the prime-order subgroup for which the denominator
+	// 1 - d*x1*x2*y1*y2 of G + q vanishes.
+	q := testPoint(t, curve,
+		"36073403901733791628036538608855607833048265717419279894770207984109002359124",
+		"18601349792348393412566423773165753240775915717680753940450614659073776275971")
+
+	if sum := curve.Add(g, q); sum.IsOnCurve() {
+		t.Error("Exceptional addition returned a point on the curve")
+	}
+	if sum := newPoint(curve).Add(q, g); sum.IsOnCurve() || !newPoint(curve).Add(sum, g).Equals(sum) {
+		t.Error("Exceptional addition didn't return the absorbing invalid point")
+	}
+
+	one, _ := curve.ScalarFromBig(big.NewInt(1))
+	if _, err := curve.MultiScalarMult([]*Scalar{one, one}, []*Point{g, q}); err != ErrInvalidPoint {
+		t.Errorf("MultiScalarMult hid an exceptional addition: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package jubjub
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidDescription = errors.New("not a valid description encoding")
+)
+
+const (
+	// GrothProofSize is the length of a Groth16 proof over BLS12-381.
+	GrothProofSize = 192
+
+	// SpendAuthSigSize is the length of a spend authorization signature.
+	SpendAuthSigSize = 64
+
+	// SpendDescriptionSize is the length of an encoded SpendDescription.
+	SpendDescriptionSize = 4*32 + GrothProofSize + SpendAuthSigSize
+
+	// OutputDescriptionSize is the length of an encoded OutputDescription.
+	OutputDescriptionSize = 3*32 + EncCiphertextSize + OutCiphertextSize + GrothProofSize
+)
+
+// SpendDescription is a Sapling spend as it appears in a v4 transaction.
+type SpendDescription struct {
+	curve *Jubjub
+
+	Cv           *Point
+	Anchor       *FieldElement
+	Nullifier    [32]byte
+	Rk           *Point
+	ZkProof      [GrothProofSize]byte
+	SpendAuthSig [SpendAuthSigSize]byte
+}
+
+// OutputDescription is a Sapling output as it appears in a v4 transaction.
+type OutputDescription struct {
+	curve *Jubjub
+
+	Cv            *Point
+	Cmu           *FieldElement
+	EphemeralKey  *Point
+	EncCiphertext [EncCiphertextSize]byte
+	OutCiphertext [OutCiphertextSize]byte
+	ZkProof       [GrothProofSize]byte
+}
+
+// SpendDescriptionFromBytes decodes a 384-byte spend description on the curve.
+func (curve *Jubjub) SpendDescriptionFromBytes(in []byte) (*SpendDescription, error) {
+	d := &SpendDescription{curve: curve}
+	if err := d.UnmarshalBinary(in); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// OutputDescriptionFromBytes decodes a 948-byte output description on the curve.
+func (curve *Jubjub) OutputDescriptionFromBytes(in []byte) (*OutputDescription, error) {
+	d := &OutputDescription{curve: curve}
+	if err := d.UnmarshalBinary(in); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// decodeDescriptionPoint reads a point that the consensus rules require to be
+// canonically encoded and not of small order.
+func (curve *Jubjub) decodeDescriptionPoint(in []byte) (*Point, error) {
+	p, err := curve.Decompress(in)
+	if err != nil {
+		return nil, err
+	}
+	if p.isSmallOrder() {
+		return nil, ErrIdentity
+	}
+	return p, nil
+}
+
+// MarshalBinary encodes the spend as cv || anchor || nf || rk || zkproof || spendAuthSig.
+func (d *SpendDescription) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, SpendDescriptionSize)
+	out = append(out, d.Cv.Compress()...)
+	out = append(out, d.Anchor.ToBytes()...)
+	out = append(out, d.Nullifier[:]...)
+	out = append(out, d.Rk.Compress()...)
+	out = append(out, d.ZkProof[:]...)
+	out = append(out, d.SpendAuthSig[:]...)
+	return out, nil
+}
+
+// UnmarshalBinary decodes a spend description. The cv and rk points must be
+// canonically encoded and not of small order, and the anchor must be a
+// canonically encoded field element. A description that was not created by
+// SpendDescriptionFromBytes is decoded on Curve().
+func (d *SpendDescription) UnmarshalBinary(in []byte) error {
+	if len(in) != SpendDescriptionSize {
+		return ErrInvalidDescription
+	}
+	if d.curve == nil {
+		d.curve = Curve()
+	}
+
+	cv, err := d.curve.decodeDescriptionPoint(in[:32])
+	if err != nil {
+		return err
+	}
+	anchor, err := d.curve.FeFromCanonicalBytes(in[32:64])
+	if err != nil {
+		return err
+	}
+	rk, err := d.curve.decodeDescriptionPoint(in[96:128])
+	if err != nil {
+		return err
+	}
+
+	d.Cv, d.Anchor, d.Rk = cv, anchor, rk
+	copy(d.Nullifier[:], in[64:96])
+	copy(d.ZkProof[:], in[128:128+GrothProofSize])
+	copy(d.SpendAuthSig[:], in[128+GrothProofSize:])
+	return nil
+}
+
+// MarshalBinary encodes the output as cv || cmu || epk || encCiphertext || outCiphertext || zkproof.
+func (d *OutputDescription) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, OutputDescriptionSize)
+	out = append(out, d.Cv.Compress()...)
+	out = append(out, d.Cmu.ToBytes()...)
+	out = append(out, d.EphemeralKey.Compress()...)
+	out = append(out, d.EncCiphertext[:]...)
+	out = append(out, d.OutCiphertext[:]...)
+	out = append(out, d.ZkProof[:]...)
+	return out, nil
+}
+
+// UnmarshalBinary decodes an output description. The cv and epk points must be
+// canonically encoded and not of small order, and cmu must be a canonically
+// encoded field element. A description that was not created by
+// OutputDescriptionFromBytes is decoded on Curve().
+func (d *OutputDescription) UnmarshalBinary(in []byte) error {
+	if len(in) != OutputDescriptionSize {
+		return ErrInvalidDescription
+	}
+	if d.curve == nil {
+		d.curve = Curve()
+	}
+
+	cv, err := d.curve.decodeDescriptionPoint(in[:32])
+	if err != nil {
+		return err
+	}
+	cmu, err := d.curve.FeFromCanonicalBytes(in[32:64])
+	if err != nil {
+		return err
+	}
+	epk, err := d.curve.decodeDescriptionPoint(in[64:96])
+	if err != nil {
+		return err
+	}
+
+	d.Cv, d.Cmu, d.EphemeralKey = cv, cmu, epk
+	in = in[96:]
+	copy(d.EncCiphertext[:], in[:EncCiphertextSize])
+	in = in[EncCiphertextSize:]
+	copy(d.OutCiphertext[:], in[:OutCiphertextSize])
+	copy(d.ZkProof[:], in[OutCiphertextSize:])
+	return nil
+}
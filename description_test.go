@@ -0,0 +1,122 @@
+package jubjub
+
+import (
+	"bytes"
+	"testing"
+)
+
+func testOutputDescription(t *testing.T) []byte {
+	tv := saplingNoteEncryption[0]
+	out := append(decodeHex(t, tv.cv), decodeHex(t, tv.cmu)...)
+	out = append(out, decodeHex(t, tv.epk)...)
+	out = append(out, decodeHex(t, tv.cEnc)...)
+	out = append(out, decodeHex(t, tv.cOut)...)
+	for i := 0; i < GrothProofSize; i++ {
+		out = append(out, byte(i))
+	}
+	return out
+}
+
+func testSpendDescription(t *testing.T) []byte {
+	kc := saplingKeyComponents[0]
+	out := append(decodeHex(t, saplingNoteEncryption[0].cv), decodeHex(t, kc.noteCmu)...)
+	out = append(out, decodeHex(t, kc.noteNf)...)
+	out = append(out, decodeHex(t, kc.ak)...)
+	for i := 0; i < GrothProofSize+SpendAuthSigSize; i++ {
+		out = append(out, byte(i))
+	}
+	return out
+}
+
+func TestOutputDescription(t *testing.T) {
+	curve := Curve()
+	enc := testOutputDescription(t)
+
+	d, err := curve.OutputDescriptionFromBytes(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := d.MarshalBinary(); !bytes.Equal(out, enc) {
+		t.Error("Output description didn't roundtrip")
+	}
+
+	// The decoded fields work with note decryption.
+	tv := saplingNoteEncryption[0]
+	sc, _ := curve.ScalarFromBytes(decodeHex(t, tv.ivk))
+	ivk, _ := curve.NewIncomingViewingKey(sc)
+	if _, _, err := TryDecryptNote(ivk, d.EphemeralKey, d.Cmu.ToBytes(), d.EncCiphertext[:]); err != nil {
+		t.Errorf("Couldn't decrypt the decoded output: %v", err)
+	}
+
+	// A zero-valued description decodes on the default curve.
+	var zero OutputDescription
+	if err := zero.UnmarshalBinary(enc); err != nil || !zero.Cv.Equals(d.Cv) {
+		t.Errorf("Couldn't decode into a zero value: %v", err)
+	}
+}
+
+func TestSpendDescription(t *testing.T) {
+	curve := Curve()
+	enc := testSpendDescription(t)
+
+	d, err := curve.SpendDescriptionFromBytes(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := d.MarshalBinary(); !bytes.Equal(out, enc) {
+		t.Error("Spend description didn't roundtrip")
+	}
+	if d.SpendAuthSig[SpendAuthSigSize-1] != byte(GrothProofSize+SpendAuthSigSize-1) {
+		t.Error("Incorrect spendAuthSig")
+	}
+}
+
+func TestDescriptionValidation(t *testing.T) {
+	curve := Curve()
+
+	// (0, -1) has order 2.
+	small := curve.Identity()
+	small.y.Neg(small.y)
+
+	nonCanonical := bytes.Repeat([]byte{0xff}, 32)
+	nonCanonical[31] = 0x7f
+
+	with := func(enc []byte, offset int, field []byte) []byte {
+		out := append([]byte{}, enc...)
+		copy(out[offset:], field)
+		return out
+	}
+
+	output := testOutputDescription(t)
+	badOutputs := map[string][]byte{
+		"truncated":            output[1:],
+		"small order cv":       with(output, 0, small.Compress()),
+		"identity epk":         with(output, 64, curve.Identity().Compress()),
+		"non-canonical epk":    with(output, 64, nonCanonical),
+		"non-canonical cmu":    with(output, 32, nonCanonical),
+		"cmu equal to modulus": with(output, 32, curve.fieldOrder.Bytes()),
+	}
+	// big.Int.Bytes is big-endian.
+	m := badOutputs["cmu equal to modulus"][32:64]
+	for i, j := 0, len(m)-1; i < j; i, j = i+1, j-1 {
+		m[i], m[j] = m[j], m[i]
+	}
+
+	for name, enc := range badOutputs {
+		if _, err := curve.OutputDescriptionFromBytes(enc); err == nil {
+			t.Errorf("Accepted an output with %s", name)
+		}
+	}
+
+	spend := testSpendDescription(t)
+	badSpends := map[string][]byte{
+		"truncated":            spend[:SpendDescriptionSize-1],
+		"small order rk":       with(spend, 96, small.Compress()),
+		"non-canonical anchor": with(spend, 32, nonCanonical),
+	}
+	for name, enc := range badSpends {
+		if _, err := curve.SpendDescriptionFromBytes(enc); err == nil {
+			t.Errorf("Accepted a spend with %s", name)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package jubjub
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnknownHRP     = errors.New("unrecognized human-readable part")
+	ErrUnknownNetwork = errors.New("unrecognized network")
+)
+
+// Network selects the human-readable prefixes used to encode addresses and keys.
+type Network int
+
+const (
+	Mainnet Network = iota
+	Testnet
+)
+
+// Human-readable parts for Sapling, indexed by Network.
+var (
+	paymentAddressHRP      = [...]string{"zs", "ztestsapling"}
+	extendedFullViewingHRP = [...]string{"zxviews", "zxviewtestsapling"}
+	extendedSpendingHRP    = [...]string{"secret-extended-key-main", "secret-extended-key-test"}
+)
+
+// Maximum lengths of the Bech32 encodings, using the longer testnet prefixes:
+// the human-readable part, the separator, the data in 5-bit groups and the
+// 6-character checksum.
+const (
+	paymentAddressMaxLength      = len("ztestsapling") + 1 + (PaymentAddressSize*8+4)/5 + 6
+	extendedFullViewingMaxLength = len("zxviewtestsapling") + 1 + (ExtendedKeySize*8+4)/5 + 6
+	extendedSpendingMaxLength    = len("secret-extended-key-test") + 1 + (ExtendedKeySize*8+4)/5 + 6
+)
+
+// hrp returns the human-readable part that net uses in table.
+func (net Network) hrp(table [2]string) (string, error) {
+	if net < 0 || int(net) >= len(table) {
+		return "", ErrUnknownNetwork
+	}
+	return table[net], nil
+}
+
+// lookupHRP returns the network that uses hrp in table.
+func lookupHRP(table [2]string, hrp string) (Network, error) {
+	for net, h := range table {
+		if h == hrp {
+			return Network(net), nil
+		}
+	}
+	return 0, ErrUnknownHRP
+}
+
+// Encode returns the Bech32 encoding of the address, such as "zs1...".
+func (addr *PaymentAddress) Encode(net Network) (string, error) {
+	hrp, err := net.hrp(paymentAddressHRP)
+	if err != nil {
+		return "", err
+	}
+	data, err := addr.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return bech32Encode(hrp, data, bech32), nil
+}
+
+// DecodePaymentAddress parses a Bech32 payment address and reports which
+// network it is for. The address is validated as by PaymentAddressFromBytes.
+func (curve *Jubjub) DecodePaymentAddress(s string) (*PaymentAddress, Network, error) {
+	hrp, data, err := bech32Decode(s, bech32, paymentAddressMaxLength)
+	if err != nil {
+		return nil, 0, err
+	}
+	net, err := lookupHRP(paymentAddressHRP, hrp)
+	if err != nil {
+		return nil, 0, err
+	}
+	addr, err := curve.PaymentAddressFromBytes(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return addr, net, nil
+}
+
+// Encode returns the Bech32 encoding of the key, such as "zxviews1...".
+func (xfvk *ExtendedFullViewingKey) Encode(net Network) (string, error) {
+	hrp, err := net.hrp(extendedFullViewingHRP)
+	if err != nil {
+		return "", err
+	}
+	data, err := xfvk.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return bech32Encode(hrp, data, bech32), nil
+}
+
+// DecodeExtendedFullViewingKey parses a Bech32 extended full viewing key and
+// reports which network it is for. The key is validated as by
+// ExtendedFullViewingKeyFromBytes.
+func (curve *Jubjub) DecodeExtendedFullViewingKey(s string) (*ExtendedFullViewingKey, Network, error) {
+	hrp, data, err := bech32Decode(s, bech32, extendedFullViewingMaxLength)
+	if err != nil {
+		return nil, 0, err
+	}
+	net, err := lookupHRP(extendedFullViewingHRP, hrp)
+	if err != nil {
+		return nil, 0, err
+	}
+	xfvk, err := curve.ExtendedFullViewingKeyFromBytes(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return xfvk, net, nil
+}
+
+// Encode returns the Bech32 encoding of the key, such as "secret-extended-key-main1...".
+func (xsk *ExtendedSpendingKey) Encode(net Network) (string, error) {
+	hrp, err := net.hrp(extendedSpendingHRP)
+	if err != nil {
+		return "", err
+	}
+	data, err := xsk.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return bech32Encode(hrp, data, bech32), nil
+}
+
+// DecodeExtendedSpendingKey parses a Bech32 extended spending key and reports
+// which network it is for.
+func (curve *Jubjub) DecodeExtendedSpendingKey(s string) (*ExtendedSpendingKey, Network, error) {
+	hrp, data, err := bech32Decode(s, bech32, extendedSpendingMaxLength)
+	if err != nil {
+		return nil, 0, err
+	}
+	net, err := lookupHRP(extendedSpendingHRP, hrp)
+	if err != nil {
+		return nil, 0, err
+	}
+	xsk, err := curve.ExtendedSpendingKeyFromBytes(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	return xsk, net, nil
+}
@@ -0,0 +1,202 @@
+package jubjub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBech32(t *testing.T) {
+	// From BIP 173.
+	valid := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"?1ezyfcl",
+	}
+	for _, s := range valid {
+		hrp, data, err := bech32Decode(s, bech32, 90)
+		if err != nil {
+			t.Errorf("Rejected %s: %v", s, err)
+			continue
+		}
+		if have := bech32Encode(hrp, data, bech32); len(data) > 0 && have != strings.ToLower(s) {
+			t.Errorf("%s didn't roundtrip: %s", s, have)
+		}
+	}
+
+	invalid := []string{
+		"1nwldj5",      // empty HRP
+		"x1b4n0q5v",    // invalid data character
+		"li1dgmt3",     // too short checksum
+		"A1G7SGD8",     // checksum calculated with uppercase HRP
+		"a12UEL5L",     // mixed case
+		"de1lg7wt\xff", // invalid character
+		"abc1rzg",      // too short checksum
+		"a12uel5m",     // wrong checksum
+	}
+	for _, s := range invalid {
+		if _, _, err := bech32Decode(s, bech32, 90); err != ErrInvalidBech32 {
+			t.Errorf("Accepted %q", s)
+		}
+	}
+
+	// A valid string that is longer than the caller allows.
+	long := bech32Encode("a", make([]byte, 100), bech32)
+	if _, _, err := bech32Decode(long, bech32, len(long)); err != nil {
+		t.Errorf("Rejected a string at the length limit: %v", err)
+	}
+	if _, _, err := bech32Decode(long, bech32, len(long)-1); err != ErrInvalidBech32 {
+		t.Error("Accepted a string over the length limit")
+	}
+}
+
+func TestEncodingLengthLimits(t *testing.T) {
+	curve := Curve()
+	tv := saplingKeyComponents[1]
+
+	var d Diversifier
+	copy(d[:], decodeHex(t, tv.defaultD))
+	pkd, _ := curve.Decompress(decodeHex(t, tv.defaultPkD))
+	addr, err := (&PaymentAddress{d, pkd}).Encode(Testnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addr) != paymentAddressMaxLength {
+		t.Errorf("Testnet address has length %d, limit is %d", len(addr), paymentAddressMaxLength)
+	}
+
+	// Extra data makes the encoding too long even though the checksum is valid.
+	for _, v := range []struct {
+		hrp    string
+		size   int
+		decode func(string) error
+	}{
+		{"ztestsapling", PaymentAddressSize, func(s string) error { _, _, err := curve.DecodePaymentAddress(s); return err }},
+		{"zxviewtestsapling", ExtendedKeySize, func(s string) error { _, _, err := curve.DecodeExtendedFullViewingKey(s); return err }},
+		{"secret-extended-key-test", ExtendedKeySize, func(s string) error { _, _, err := curve.DecodeExtendedSpendingKey(s); return err }},
+	} {
+		long := bech32Encode(v.hrp, make([]byte, v.size+1), bech32)
+		if err := v.decode(long); err != ErrInvalidBech32 {
+			t.Errorf("Accepted an overlong %s encoding: %v", v.hrp, err)
+		}
+	}
+
+	if _, err := (&PaymentAddress{d, pkd}).Encode(Network(2)); err != ErrUnknownNetwork {
+		t.Error("Encoded an address for an unknown network")
+	}
+}
+
+func TestEncodePaymentAddress(t *testing.T) {
+	curve := Curve()
+
+	vectors := []struct {
+		net     Network
+		encoded string
+		tv      int
+	}{
+		{Mainnet, "zs17xwek7t788enw3zc88d5e54s4tz006uv5yclzet8c3z6j423ymfu98c5u0thd6zp4e6p2jumnna", 0},
+		{Testnet, "ztestsapling14mccpahrfc65hzy0sxntz04rxmwm0fnmkzdqu68f608m8ysssv028g5khgy6jgsxplfckv398hq", 1},
+	}
+
+	for i, v := range vectors {
+		tv := saplingKeyComponents[v.tv]
+		var d Diversifier
+		copy(d[:], decodeHex(t, tv.defaultD))
+		pkd, _ := curve.Decompress(decodeHex(t, tv.defaultPkD))
+		addr := &PaymentAddress{d, pkd}
+
+		if have, err := addr.Encode(v.net); err != nil || have != v.encoded {
+			t.Errorf("Incorrect encoding for test %d:\nWant: %s\nHave: %s", i, v.encoded, have)
+		}
+
+		decoded, net, err := curve.DecodePaymentAddress(v.encoded)
+		if err != nil {
+			t.Fatalf("Couldn't decode test %d: %v", i, err)
+		}
+		if net != v.net || decoded.Diversifier != d || !decoded.PkD.Equals(pkd) {
+			t.Errorf("Incorrect decoding for test %d", i)
+		}
+	}
+
+	// A payment address string is not a viewing key.
+	if _, _, err := curve.DecodeExtendedFullViewingKey(vectors[0].encoded); err != ErrUnknownHRP {
+		t.Error("Decoded an address as a viewing key")
+	}
+}
+
+func TestPaymentAddressValidation(t *testing.T) {
+	curve := Curve()
+	tv := saplingKeyComponents[0]
+	valid := append(decodeHex(t, tv.defaultD), decodeHex(t, tv.defaultPkD)...)
+
+	if _, err := curve.PaymentAddressFromBytes(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	// (0, -1) has order 2.
+	small := curve.Identity()
+	small.y.Neg(small.y)
+
+	invalid := map[string][]byte{
+		"identity pk_d":    append(decodeHex(t, tv.defaultD), curve.Identity().Compress()...),
+		"small order pk_d": append(decodeHex(t, tv.defaultD), small.Compress()...),
+		"truncated":        valid[:PaymentAddressSize-1],
+	}
+
+	// Find a diversifier with no diversified base.
+	var d Diversifier
+	for {
+		if _, err := curve.DiversifyHash(d); err != nil {
+			break
+		}
+		d[0]++
+	}
+	invalid["invalid diversifier"] = append(d[:], valid[DiversifierLength:]...)
+
+	for name, enc := range invalid {
+		if _, err := curve.PaymentAddressFromBytes(enc); err != ErrInvalidAddress {
+			t.Errorf("Accepted address with %s", name)
+		}
+		if _, _, err := curve.DecodePaymentAddress(bech32Encode("zs", enc, bech32)); err != ErrInvalidAddress {
+			t.Errorf("Decoded Bech32 address with %s", name)
+		}
+	}
+}
+
+func TestEncodeExtendedKeys(t *testing.T) {
+	curve := Curve()
+	tv := saplingZip32[0]
+
+	xfvkEnc := "zxviews1qqqqqqqqqqqqqqxsj37ykqalw23h4dz0wgnk688nlhxha0e7wv6gklj4p46jqxrx36f5gtjlalal79h8y9eq9hrnqeeflll7skh4dqauufjzu0htt5u8rh8gulk7eczt39gyzlu9hftkjxmc83zmrgn5ytd3dy7uadnmzqgx89vgfzgrywuafyeuqgwm3x70we7lyxthktlsdquysvs6fh62lvsh0stukadh0940kw0s7053eyjxqld9d756yr3gx5ymez37lxt2zuscwhlr7"
+	xskEnc := "secret-extended-key-test1qqqqqqqqqqqqqqxsj37ykqalw23h4dz0wgnk688nlhxha0e7wv6gklj4p46jqxrx36mvqryn6dsr9wdzdr5eap4gvpmk2c9lp6purggt28mq0j25wsjsdqsyah5rktclhkz0ndza07vkut4apgps45jrkj8d88m532yzr6sx89vgfzgrywuafyeuqgwm3x70we7lyxthktlsdquysvs6fh62lvsh0stukadh0940kw0s7053eyjxqld9d756yr3gx5ymez37lxt2zusn4x0ah"
+
+	xfvk, net, err := curve.DecodeExtendedFullViewingKey(xfvkEnc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc, _ := xfvk.MarshalBinary(); net != Mainnet || !bytes.Equal(enc, decodeHex(t, tv.xfvk)) {
+		t.Error("Incorrect decoding of the extended full viewing key")
+	}
+	if have, err := xfvk.Encode(Mainnet); err != nil || have != xfvkEnc {
+		t.Errorf("Incorrect encoding of the extended full viewing key:\nWant: %s\nHave: %s", xfvkEnc, have)
+	}
+
+	xsk, net, err := curve.DecodeExtendedSpendingKey(xskEnc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc, _ := xsk.MarshalBinary(); net != Testnet || !bytes.Equal(enc, decodeHex(t, tv.xsk)) {
+		t.Error("Incorrect decoding of the extended spending key")
+	}
+	if have, err := xsk.Encode(Testnet); err != nil || have != xskEnc {
+		t.Errorf("Incorrect encoding of the extended spending key:\nWant: %s\nHave: %s", xskEnc, have)
+	}
+
+	// An identity ak must be rejected.
+	bad := decodeHex(t, tv.xfvk)
+	copy(bad[41:73], curve.Identity().Compress())
+	if _, _, err := curve.DecodeExtendedFullViewingKey(bech32Encode("zxviews", bad, bech32)); err != ErrInvalidExtendedKey {
+		t.Error("Accepted an extended full viewing key with an identity ak")
+	}
+}
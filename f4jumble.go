@@ -0,0 +1,91 @@
+package jubjub
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidJumbleLength = errors.New("F4Jumble input length out of range")
+)
+
+// F4Jumble is the unkeyed four-round Feistel permutation from ZIP 316. Unified
+// encodings jumble their contents so that changing any part of an address
+// changes every character of its string form.
+const (
+	f4JumbleHashLen = 64
+	f4JumbleMinLen  = 48
+	f4JumbleMaxLen  = 4194368
+)
+
+// f4JumbleH is the round function BLAKE2b-l_L("UA_F4Jumble_H" || [i, 0, 0], u).
+func f4JumbleH(i byte, u []byte, size int) []byte {
+	h := newBlake2b(size, "UA_F4Jumble_H"+string([]byte{i, 0, 0}))
+	h.Write(u)
+	return h.Sum(nil)
+}
+
+// f4JumbleG expands u to size bytes by concatenating
+// BLAKE2b-512("UA_F4Jumble_G" || [i] || I2LEOSP_16(j), u) for j = 0, 1, ...
+func f4JumbleG(i byte, u []byte, size int) []byte {
+	out := make([]byte, 0, size+f4JumbleHashLen)
+	var person [16]byte
+	copy(person[:], "UA_F4Jumble_G")
+	person[13] = i
+	for j := 0; len(out) < size; j++ {
+		binary.LittleEndian.PutUint16(person[14:], uint16(j))
+		h := newBlake2b(f4JumbleHashLen, string(person[:]))
+		h.Write(u)
+		out = h.Sum(out)
+	}
+	return out[:size]
+}
+
+func xorInto(dst, src []byte) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+// f4Jumble returns the jumbled form of m as a newly allocated slice.
+func f4Jumble(m []byte) ([]byte, error) {
+	if len(m) < f4JumbleMinLen || len(m) > f4JumbleMaxLen {
+		return nil, ErrInvalidJumbleLength
+	}
+
+	out := append([]byte{}, m...)
+	lL := len(m) / 2
+	if lL > f4JumbleHashLen {
+		lL = f4JumbleHashLen
+	}
+	a, b := out[:lL], out[lL:]
+
+	xorInto(b, f4JumbleG(0, a, len(b)))
+	xorInto(a, f4JumbleH(0, b, lL))
+	xorInto(b, f4JumbleG(1, a, len(b)))
+	xorInto(a, f4JumbleH(1, b, lL))
+
+	return out, nil
+}
+
+// f4JumbleInv inverts f4Jumble.
+func f4JumbleInv(m []byte) ([]byte, error) {
+	if len(m) < f4JumbleMinLen || len(m) > f4JumbleMaxLen {
+		return nil, ErrInvalidJumbleLength
+	}
+
+	out := append([]byte{}, m...)
+	lL := len(m) / 2
+	if lL > f4JumbleHashLen {
+		lL = f4JumbleHashLen
+	}
+	c, d := out[:lL], out[lL:]
+
+	xorInto(c, f4JumbleH(1, d, lL))
+	xorInto(d, f4JumbleG(1, c, len(d)))
+	xorInto(c, f4JumbleH(0, d, lL))
+	xorInto(d, f4JumbleG(0, c, len(d)))
+
+	return out, nil
+}
@@ -0,0 +1,103 @@
+package jubjub
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+)
+
+// ff1 implements the FF1 format-preserving cipher from NIST SP 800-38G,
+// specialized to the parameters ZIP 32 uses for diversifiers: AES-256, radix 2,
+// 88-bit messages and an empty tweak. The message is split into two 44-bit
+// halves that are held as integers.
+type ff1 struct {
+	block cipher.Block
+
+	// macP is the CBC-MAC state after the fixed first block P.
+	macP [aes.BlockSize]byte
+}
+
+const (
+	ff1Bits     = 88
+	ff1HalfBits = ff1Bits / 2
+	ff1HalfMask = 1<<ff1HalfBits - 1
+	ff1Rounds   = 10
+)
+
+func newFF1(key []byte) *ff1 {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic("jubjub: invalid FF1 key: " + err.Error())
+	}
+
+	// P = [1, 2, 1] || [radix]^3 || [10] || [u mod 256] || [n]^4 || [t]^4
+	P := [aes.BlockSize]byte{1, 2, 1, 0, 0, 2, 10, ff1HalfBits, 0, 0, 0, ff1Bits, 0, 0, 0, 0}
+
+	f := &ff1{block: block}
+	block.Encrypt(f.macP[:], P[:])
+	return f
+}
+
+// round computes y mod 2^44, where y is the first d = 12 bytes of
+// PRF(P || Q) read as a big-endian integer and Q = [0]^9 || [i] || NUM(x)
+// as a 6-byte big-endian string.
+func (f *ff1) round(i byte, x uint64) uint64 {
+	var Q [aes.BlockSize]byte
+	Q[9] = i
+	Q[10] = byte(x >> 40)
+	Q[11] = byte(x >> 32)
+	binary.BigEndian.PutUint32(Q[12:], uint32(x))
+
+	var R [aes.BlockSize]byte
+	for j := range Q {
+		R[j] = f.macP[j] ^ Q[j]
+	}
+	f.block.Encrypt(R[:], R[:])
+
+	// Only the low 44 bits of the 96-bit y matter, and they're in R[6:12].
+	return (uint64(binary.BigEndian.Uint16(R[6:8]))<<32 | uint64(binary.BigEndian.Uint32(R[8:12]))) & ff1HalfMask
+}
+
+func (f *ff1) encrypt(a, b uint64) (uint64, uint64) {
+	for i := 0; i < ff1Rounds; i++ {
+		a, b = b, (a+f.round(byte(i), b))&ff1HalfMask
+	}
+	return a, b
+}
+
+func (f *ff1) decrypt(a, b uint64) (uint64, uint64) {
+	for i := ff1Rounds - 1; i >= 0; i-- {
+		a, b = (b-f.round(byte(i), a))&ff1HalfMask, a
+	}
+	return a, b
+}
+
+// splitBits reads 88 bits least significant bit first and returns the numeral
+// values of the two halves. FF1 reads each half's bits most significant first.
+func splitBits(in [DiversifierLength]byte) (uint64, uint64) {
+	var a, b uint64
+	for k := 0; k < ff1Bits; k++ {
+		bit := uint64(in[k/8]>>uint(k%8)) & 1
+		if k < ff1HalfBits {
+			a = a<<1 | bit
+		} else {
+			b = b<<1 | bit
+		}
+	}
+	return a, b
+}
+
+// joinBits is the inverse of splitBits.
+func joinBits(a, b uint64) [DiversifierLength]byte {
+	var out [DiversifierLength]byte
+	for k := 0; k < ff1Bits; k++ {
+		var bit uint64
+		if k < ff1HalfBits {
+			bit = a >> uint(ff1HalfBits-1-k) & 1
+		} else {
+			bit = b >> uint(ff1Bits-1-k) & 1
+		}
+		out[k/8] |= byte(bit) << uint(k%8)
+	}
+	return out
+}
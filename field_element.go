@@ -3,6 +3,12 @@ package jubjub
 import (
 	"math/big"
 	"math/bits"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNonCanonicalFieldElement = errors.New("field element encoding was not reduced")
 )
 
 // FieldElement is an element of an arbitrary integer field.
@@ -23,6 +29,20 @@ func (curve *Jubjub) FeFromBytes(in []byte) *FieldElement {
 	return fe.fromBytes(in)
 }
 
+// FeFromCanonicalBytes reads a field element from its 32-byte little-endian
+// encoding. Unlike FeFromBytes, it rejects encodings of values that are not
+// less than the field order instead of reducing them.
+func (curve *Jubjub) FeFromCanonicalBytes(in []byte) (*FieldElement, error) {
+	if len(in) != (curve.fieldOrder.BitLen()+7)/8 {
+		return nil, ErrNonCanonicalFieldElement
+	}
+	fe := newFieldElement(nil, curve.fieldOrder).fromCanonicalBytes(in)
+	if fe == nil {
+		return nil, ErrNonCanonicalFieldElement
+	}
+	return fe, nil
+}
+
 // newFieldElement returns a new element of the field defined by `order` initialized to the value of `n`.
 func newFieldElement(n, order *big.Int) *FieldElement {
 	if n == nil {
@@ -87,6 +107,18 @@ func (z *FieldElement) Neg(x *FieldElement) *FieldElement {
 	return z
 }
 
+// condSwap swaps the values of z and x if b == 1 and leaves them unchanged if b == 0,
+// performing the same operations in both cases.
+func (z *FieldElement) condSwap(x *FieldElement, b uint) {
+	// mask is either 0 or -1, which is all ones in two's complement.
+	mask := new(big.Int).Neg(new(big.Int).SetUint64(uint64(b & 1)))
+
+	t := new(big.Int).Xor(z.n, x.n)
+	t.And(t, mask)
+	z.n.Xor(z.n, t)
+	x.n.Xor(x.n, t)
+}
+
 // ModInverse sets z to the multiplicative inverse of x in the field and returns z.
 func (z *FieldElement) ModInverse(x *FieldElement) *FieldElement {
 	z.n.ModInverse(x.n, z.fieldOrder)
@@ -124,6 +156,23 @@ func (z *FieldElement) fromBytes(ser []byte) *FieldElement {
 	return z
 }
 
+// fromCanonicalBytes is like fromBytes, but it returns nil and leaves z unchanged
+// if the encoded value is not already reduced by the field order.
+func (z *FieldElement) fromCanonicalBytes(ser []byte) *FieldElement {
+	be := make([]byte, len(ser))
+	for i := range ser {
+		be[len(ser)-1-i] = ser[i]
+	}
+
+	n := new(big.Int).SetBytes(be)
+	if n.Cmp(z.fieldOrder) >= 0 {
+		return nil
+	}
+
+	z.n.Set(n)
+	return z
+}
+
 // ToBytes converts z to a little-endian bytestring and returns the bytes.
 func (z *FieldElement) ToBytes() []byte {
 	z.n.Mod(z.n, z.fieldOrder)
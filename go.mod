@@ -1,5 +1,10 @@
 module github.com/gtank/jubjub
 
-go 1.11
+go 1.17
 
-require github.com/pkg/errors v0.9.1
+require (
+	github.com/pkg/errors v0.9.1
+	golang.org/x/crypto v0.10.0
+)
+
+require golang.org/x/sys v0.9.0 // indirect
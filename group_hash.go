@@ -0,0 +1,94 @@
+package jubjub
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrGroupHashFailed = errors.New("could not find a group hash for the input")
+)
+
+// urs is the uniform random string used as the first 64 bytes of every GroupHash input.
+const urs = "096b36a5804bfacef1691e173c366a47ff5ba84a44f26ddd7e8d9f79d5b42df0"
+
+// GroupHash computes the Sapling hash into the prime-order subgroup
+// GroupHash^J(r)*_URS(D, M), where D is an 8-byte personalization string.
+// It returns ErrInvalidPoint if the hash does not decode to a point and
+// ErrIdentity if the point lies in the small-order torsion.
+func (curve *Jubjub) GroupHash(personalization string, msg []byte) (*Point, error) {
+	h := newBlake2s(personalization)
+	h.Write([]byte(urs))
+	h.Write(msg)
+
+	p, err := curve.Decompress(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	p.MulByCofactor()
+	if p.IsIdentity() {
+		return nil, ErrIdentity
+	}
+
+	return p, nil
+}
+
+// FindGroupHash computes GroupHash(D, M || [i]) for the first byte i in 0..255 that succeeds.
+func (curve *Jubjub) FindGroupHash(personalization string, msg []byte) (*Point, error) {
+	buf := make([]byte, len(msg)+1)
+	copy(buf, msg)
+
+	for i := 0; i < 256; i++ {
+		buf[len(msg)] = byte(i)
+		p, err := curve.GroupHash(personalization, buf)
+		if err == nil {
+			return p, nil
+		}
+	}
+
+	return nil, ErrGroupHashFailed
+}
+
+// generator returns FindGroupHash(D, M) as a newly allocated point. The Sapling
+// generators are fixed by the protocol, so each is computed on first use and
+// cached on the curve.
+func (curve *Jubjub) generator(personalization string, msg []byte) *Point {
+	key := personalization + string(msg)
+
+	curve.generatorsMu.Lock()
+	defer curve.generatorsMu.Unlock()
+
+	if curve.generators == nil {
+		curve.generators = make(map[string]*Point)
+	}
+
+	g, ok := curve.generators[key]
+	if !ok {
+		var err error
+		g, err = curve.FindGroupHash(personalization, msg)
+		if err != nil {
+			panic("jubjub: no generator for " + personalization)
+		}
+		curve.generators[key] = g
+	}
+
+	return g.Clone()
+}
+
+// SpendingKeyGenerator returns G = FindGroupHash("Zcash_G_", ""), the base for
+// spend authorization keys ak = [ask] G.
+func (curve *Jubjub) SpendingKeyGenerator() *Point {
+	return curve.generator("Zcash_G_", nil)
+}
+
+// ProofGenerationKeyGenerator returns H = FindGroupHash("Zcash_H_", ""), the
+// base for nullifier deriving keys nk = [nsk] H.
+func (curve *Jubjub) ProofGenerationKeyGenerator() *Point {
+	return curve.generator("Zcash_H_", nil)
+}
+
+// ValueCommitmentRandomnessGenerator returns R = FindGroupHash("Zcash_cv", "r"),
+// the base for value commitment randomness and binding signature keys.
+func (curve *Jubjub) ValueCommitmentRandomnessGenerator() *Point {
+	return curve.generator("Zcash_cv", []byte("r"))
+}
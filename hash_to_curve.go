@@ -0,0 +1,320 @@
+package jubjub
+
+import (
+	"crypto/subtle"
+	"hash"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidExpandLength = errors.New("requested too many bytes from expand_message_xmd")
+)
+
+// RFC 9380 hashing to the curve. There is no standardized suite for Jubjub or
+// the other curves here, so callers should choose a DST that names the suite
+// they use, such as "MYAPP-V01-CS01-with-jubjub_XMD:SHA-512_ELL2_RO_".
+
+// hashToFieldSecurity is k, the target security level in bits, which sets the
+// number of bytes hashed per field element to L = ceil((ceil(log2(p)) + k) / 8).
+const hashToFieldSecurity = 128
+
+// ExpandMessageXMD is expand_message_xmd from RFC 9380 section 5.3.1. It
+// returns length uniformly random bytes derived from msg under the domain
+// separation tag dst using the Merkle–Damgård hash h, such as sha256.New or
+// sha512.New. A dst longer than 255 bytes is first hashed as in section 5.3.3.
+func ExpandMessageXMD(h func() hash.Hash, msg, dst []byte, length int) ([]byte, error) {
+	H := h()
+	bLen, sLen := H.Size(), H.BlockSize()
+
+	ell := (length + bLen - 1) / bLen
+	if length < 0 || ell > 255 || length > 65535 {
+		return nil, ErrInvalidExpandLength
+	}
+
+	if len(dst) > 255 {
+		H.Write([]byte("H2C-OVERSIZE-DST-"))
+		H.Write(dst)
+		dst = H.Sum(nil)
+		H.Reset()
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	// b_0 = H(Z_pad || msg || I2OSP(len_in_bytes, 2) || I2OSP(0, 1) || DST_prime)
+	H.Write(make([]byte, sLen))
+	H.Write(msg)
+	H.Write([]byte{byte(length >> 8), byte(length), 0})
+	H.Write(dstPrime)
+	b0 := H.Sum(nil)
+
+	// b_i = H(strxor(b_0, b_(i - 1)) || I2OSP(i, 1) || DST_prime), with b_1
+	// taking an all-zero b_0 in the strxor.
+	out := make([]byte, 0, ell*bLen)
+	prev := make([]byte, bLen)
+	for i := 1; i <= ell; i++ {
+		xorInto(prev, b0)
+
+		H.Reset()
+		H.Write(prev)
+		H.Write([]byte{byte(i)})
+		H.Write(dstPrime)
+		prev = H.Sum(prev[:0])
+
+		out = append(out, prev...)
+	}
+
+	return out[:length], nil
+}
+
+// HashToField is hash_to_field from RFC 9380 section 5.2 for the curve's base
+// field, which returns count field elements derived from msg with
+// ExpandMessageXMD.
+func (curve *Jubjub) HashToField(h func() hash.Hash, msg, dst []byte, count int) ([]*FieldElement, error) {
+	L := (curve.fieldOrder.BitLen() + hashToFieldSecurity + 7) / 8
+
+	uniform, err := ExpandMessageXMD(h, msg, dst, count*L)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*FieldElement, count)
+	for i := range out {
+		n := new(big.Int).SetBytes(uniform[i*L : (i+1)*L])
+		out[i] = curve.newFieldElement(n.Mod(n, curve.fieldOrder))
+	}
+	return out, nil
+}
+
+// h2cConstants are the field constants of the straight-line Elligator 2 map
+// and of sqrt_ct from RFC 9380 appendix I.4.
+type h2cConstants struct {
+	// z is the Elligator 2 constant Z, which is also the non-square c4 of
+	// sqrt_ct.
+	z *FieldElement
+
+	// pMinus2 and legendre are the exponents of inv0 and is_square.
+	pMinus2, legendre *big.Int
+
+	// c1 is the 2-adicity of p - 1, c3 = (c2 - 1) / 2 for the odd part c2 of
+	// p - 1, and c5 = Z^c2.
+	c1 int
+	c3 *big.Int
+	c5 *FieldElement
+}
+
+// h2c returns the curve's hash-to-curve constants. Z is the first of 1, -1,
+// 2, -2, ... that is not a square in the field, as chosen by find_z_ell2 in
+// RFC 9380 appendix H.3. The constants are computed on first use and must not
+// be modified.
+func (curve *Jubjub) h2c() *h2cConstants {
+	curve.h2cOnce.Do(func() {
+		p := curve.fieldOrder
+		c := &h2cConstants{
+			pMinus2:  new(big.Int).Sub(p, big.NewInt(2)),
+			legendre: new(big.Int).Rsh(new(big.Int).Sub(p, big.NewInt(1)), 1),
+		}
+		for ctr := int64(1); c.z == nil; ctr++ {
+			for _, z := range []int64{ctr, -ctr} {
+				n := new(big.Int).Mod(big.NewInt(z), p)
+				if big.Jacobi(n, p) == -1 {
+					c.z = curve.newFieldElement(n)
+					break
+				}
+			}
+		}
+
+		c2 := new(big.Int).Sub(p, big.NewInt(1))
+		for c2.Bit(0) == 0 {
+			c2.Rsh(c2, 1)
+			c.c1++
+		}
+		c.c3 = new(big.Int).Rsh(c2, 1)
+		c.c5 = curve.newFieldElement(new(big.Int).Exp(c.z.n, c2, p))
+		curve.h2cConsts = c
+	})
+	return curve.h2cConsts
+}
+
+// elligator2Z returns the Elligator 2 constant Z.
+func (curve *Jubjub) elligator2Z() *FieldElement {
+	return curve.h2c().z
+}
+
+// sgn0 returns the sign of a field element as defined in RFC 9380 section 4.1.
+func sgn0(x *FieldElement) uint {
+	return x.n.Bit(0)
+}
+
+// The helpers below follow the straight-line conventions of RFC 9380: every
+// call performs the same sequence of field operations whatever the values, and
+// selections use masks instead of branches.
+
+// cmov sets z to y if c == 1 and leaves it unchanged if c == 0, as CMOV(z, y, c)
+// in RFC 9380 section 4, and returns z.
+func (z *FieldElement) cmov(y *FieldElement, c uint) *FieldElement {
+	t := newFieldElement(new(big.Int).Set(y.n), y.fieldOrder)
+	z.condSwap(t, c)
+	return z
+}
+
+// ctEqual returns 1 if x == y and 0 otherwise by comparing fixed-length
+// encodings.
+func ctEqual(x, y *FieldElement) uint {
+	return uint(subtle.ConstantTimeCompare(x.ToBytes(), y.ToBytes()))
+}
+
+// inv0 sets z to x^(p - 2), which is 1 / x for nonzero x and 0 for x = 0, and
+// returns z.
+func (curve *Jubjub) inv0(z, x *FieldElement) *FieldElement {
+	z.n.Exp(x.n, curve.h2c().pMinus2, curve.fieldOrder)
+	return z
+}
+
+// isSquare returns 1 if x is zero or a square and 0 otherwise.
+func (curve *Jubjub) isSquare(x *FieldElement) uint {
+	l := curve.newFieldElement(new(big.Int).Exp(x.n, curve.h2c().legendre, curve.fieldOrder))
+	return ctEqual(l, curve.fieldOne) | ctEqual(x, curve.fieldZero)
+}
+
+// sqrtCT returns a square root of x, which must be a square, with sqrt_ct
+// from RFC 9380 appendix I.4. Its loop runs a fixed number of times for the
+// field, unlike Tonelli-Shanks in big.Int.ModSqrt.
+func (curve *Jubjub) sqrtCT(x *FieldElement) *FieldElement {
+	c := curve.h2c()
+
+	z := curve.newFieldElement(new(big.Int).Exp(x.n, c.c3, curve.fieldOrder))
+	t := curve.newFieldElement(nil).Mul(z, z)
+	t.Mul(t, x)
+	z.Mul(z, x)
+	b := curve.newFieldElement(nil).Set(t)
+	cc := curve.newFieldElement(nil).Set(c.c5)
+
+	zt := curve.newFieldElement(nil)
+	tt := curve.newFieldElement(nil)
+	for i := c.c1; i >= 2; i-- {
+		for j := 1; j <= i-2; j++ {
+			b.Mul(b, b)
+		}
+		e := ctEqual(b, curve.fieldOne)
+		zt.Mul(z, cc)
+		z.cmov(zt, 1^e)
+		cc.Mul(cc, cc)
+		tt.Mul(t, cc)
+		t.cmov(tt, 1^e)
+		b.Set(t)
+	}
+	return z
+}
+
+// elligator2 maps u to a point (s, t) on the Montgomery form of the curve with
+// the straight-line map_to_curve_elligator2 from RFC 9380 section 6.7.1, with
+// J = A and K = B. Both candidate x-coordinates and their images are always
+// computed and the result is selected with cmov.
+func (curve *Jubjub) elligator2(u *FieldElement) (s, t *FieldElement) {
+	J, K := curve.MontgomeryParams()
+	Z := curve.elligator2Z()
+
+	kInv := curve.newFieldElement(nil).ModInverse(K)
+	jOverK := curve.newFieldElement(nil).Mul(J, kInv)
+	kInv2 := curve.newFieldElement(nil).Mul(kInv, kInv)
+	negJOverK := curve.newFieldElement(nil).Neg(jOverK)
+
+	// x1 = -(J / K) * inv0(1 + Z * u^2), or -(J / K) if that is zero
+	x1 := curve.newFieldElement(nil).Mul(u, u)
+	x1.Mul(x1, Z).Add(x1, curve.fieldOne)
+	curve.inv0(x1, x1).Mul(x1, negJOverK)
+	x1.cmov(negJOverK, ctEqual(x1, curve.fieldZero))
+
+	// gx1 = x1^3 + (J / K) * x1^2 + x1 / K^2
+	gx1 := curve.newFieldElement(nil).Add(x1, jOverK)
+	gx1.Mul(gx1, x1).Add(gx1, kInv2).Mul(gx1, x1)
+
+	// x2 = -x1 - (J / K), and gx2 likewise
+	x2 := curve.newFieldElement(nil).Sub(negJOverK, x1)
+	gx2 := curve.newFieldElement(nil).Add(x2, jOverK)
+	gx2.Mul(gx2, x2).Add(gx2, kInv2).Mul(gx2, x2)
+
+	// If gx1 is square, take x = x1 and the root with sgn0(y) = 1. Otherwise
+	// gx2 is square, and y is its root with sgn0(y) = 0.
+	e := curve.isSquare(gx1)
+	x := x2.cmov(x1, e)
+	y2 := gx2.cmov(gx1, e)
+	y := curve.sqrtCT(y2)
+	negY := curve.newFieldElement(nil).Neg(y)
+	y.cmov(negY, e^sgn0(y))
+
+	s = x.Mul(x, K)
+	t = y.Mul(y, K)
+	return s, t
+}
+
+// MapToCurve is map_to_curve from RFC 9380: Elligator 2 onto the Montgomery
+// form of the curve followed by the rational map to twisted Edwards form from
+// appendix D.1, which sends the exceptional points to the identity. Its output
+// is not in the prime-order subgroup until the cofactor is cleared.
+//
+// MapToCurve follows the straight-line procedures of RFC 9380: it doesn't
+// branch on u or on any intermediate value, computes both Elligator 2
+// candidates, selects between them with masks, and uses inv0 and sqrt_ct with
+// fixed exponents and iteration counts. The field arithmetic underneath is
+// big.Int, which isn't fixed width, so its timing can still vary with the
+// values; MapToCurve is not guaranteed constant time.
+func (curve *Jubjub) MapToCurve(u *FieldElement) *Point {
+	s, t := curve.elligator2(u)
+
+	// (x, y) = (s / t, (s - 1) / (s + 1)), or the identity if either
+	// denominator is zero, in which case inv0 makes x zero.
+	yd := curve.newFieldElement(nil).Add(s, curve.fieldOne)
+	inv := curve.newFieldElement(nil).Mul(yd, t)
+	curve.inv0(inv, inv)
+	e := ctEqual(inv, curve.fieldZero)
+
+	x := curve.newFieldElement(nil).Mul(inv, yd)
+	x.Mul(x, s)
+	y := curve.newFieldElement(nil).Mul(inv, t)
+	y.Mul(y, curve.newFieldElement(nil).Sub(s, curve.fieldOne))
+	y.cmov(curve.fieldOne, e)
+
+	return &Point{curve, x, y}
+}
+
+// HashToCurve is the random-oracle encoding hash_to_curve from RFC 9380
+// section 3. It hashes msg to two field elements, maps each to the curve, and
+// clears the cofactor of their sum, so the result is in the prime-order
+// subgroup and indistinguishable from a random oracle. Like MapToCurve, it is
+// not guaranteed constant time. On curves with an incomplete addition law the
+// sum can be undefined, and HashToCurve then returns ErrInvalidPoint.
+func (curve *Jubjub) HashToCurve(h func() hash.Hash, msg, dst []byte) (*Point, error) {
+	u, err := curve.HashToField(h, msg, dst, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	p := curve.MapToCurve(u[0])
+	p.Add(p, curve.MapToCurve(u[1]))
+	if !p.IsOnCurve() {
+		return nil, ErrInvalidPoint
+	}
+	if !p.MulByCofactor().IsOnCurve() {
+		return nil, ErrInvalidPoint
+	}
+	return p, nil
+}
+
+// EncodeToCurve is the nonuniform encoding encode_to_curve from RFC 9380
+// section 3. It costs half as much as HashToCurve, but its output distribution
+// is distinguishable from uniform. It returns ErrInvalidPoint if clearing the
+// cofactor hits an undefined addition on a curve with an incomplete law.
+func (curve *Jubjub) EncodeToCurve(h func() hash.Hash, msg, dst []byte) (*Point, error) {
+	u, err := curve.HashToField(h, msg, dst, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	p := curve.MapToCurve(u[0]).MulByCofactor()
+	if !p.IsOnCurve() {
+		return nil, ErrInvalidPoint
+	}
+	return p, nil
+}
@@ -0,0 +1,212 @@
+package jubjub
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// https://www.rfc-editor.org/rfc/rfc9380.html#appendix-K
+var expandMessageXMDTests = []struct {
+	h      func() hash.Hash
+	dst    string
+	msg    string
+	length int
+	out    string
+}{
+	{sha256.New, "QUUX-V01-CS02-with-expander-SHA256-128", "", 0x20, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+	{sha256.New, "QUUX-V01-CS02-with-expander-SHA256-128", "abc", 0x20, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+	{sha256.New, "QUUX-V01-CS02-with-expander-SHA256-128", "abcdef0123456789", 0x20, "eff31487c770a893cfb36f912fbfcbff40d5661771ca4b2cb4eafe524333f5c1"},
+	{sha256.New, "QUUX-V01-CS02-with-expander-SHA256-128", "", 0x80, "af84c27ccfd45d41914fdff5df25293e221afc53d8ad2ac06d5e3e29485dadbee0d121587713a3e0dd4d5e69e93eb7cd4f5df4cd103e188cf60cb02edc3edf18eda8576c412b18ffb658e3dd6ec849469b979d444cf7b26911a08e63cf31f9dcc541708d3491184472c2c29bb749d4286b004ceb5ee6b9a7fa5b646c993f0ced"},
+	{sha256.New, "QUUX-V01-CS02-with-expander-SHA256-128", "abc", 0x80, "abba86a6129e366fc877aab32fc4ffc70120d8996c88aee2fe4b32d6c7b6437a647e6c3163d40b76a73cf6a5674ef1d890f95b664ee0afa5359a5c4e07985635bbecbac65d747d3d2da7ec2b8221b17b0ca9dc8a1ac1c07ea6a1e60583e2cb00058e77b7b72a298425cd1b941ad4ec65e8afc50303a22c0f99b0509b4c895f40"},
+	{sha512.New, "QUUX-V01-CS02-with-expander-SHA512-256", "", 0x20, "6b9a7312411d92f921c6f68ca0b6380730a1a4d982c507211a90964c394179ba"},
+}
+
+func TestExpandMessageXMD(t *testing.T) {
+	for i, tt := range expandMessageXMDTests {
+		out, err := ExpandMessageXMD(tt.h, []byte(tt.msg), []byte(tt.dst), tt.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(out) != tt.out {
+			t.Errorf("Incorrect output for test %d:\nWant: %s\nHave: %x", i, tt.out, out)
+		}
+	}
+
+	// An oversized DST is replaced by its hash.
+	long := []byte(strings.Repeat("D", 256))
+	h := sha256.New()
+	h.Write([]byte("H2C-OVERSIZE-DST-"))
+	h.Write(long)
+	a, _ := ExpandMessageXMD(sha256.New, []byte("abc"), long, 32)
+	b, _ := ExpandMessageXMD(sha256.New, []byte("abc"), h.Sum(nil), 32)
+	if !bytes.Equal(a, b) {
+		t.Error("Oversized DST wasn't hashed")
+	}
+
+	if _, err := ExpandMessageXMD(sha256.New, nil, []byte("DST"), 256*32); err != ErrInvalidExpandLength {
+		t.Error("Expanded to more than 255 blocks")
+	}
+}
+
+func TestHashToField(t *testing.T) {
+	curve := Curve()
+	dst := []byte("jubjub-test-V01-CS01-with-jubjub_XMD:SHA-256_ELL2_RO_")
+
+	u, err := curve.HashToField(sha256.New, []byte("abc"), dst, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// L = ceil((255 + 128) / 8) = 48 bytes per element.
+	uniform, _ := ExpandMessageXMD(sha256.New, []byte("abc"), dst, 96)
+	for i, e := range u {
+		n := new(big.Int).SetBytes(uniform[48*i : 48*(i+1)])
+		want := curve.newFieldElement(n.Mod(n, curve.fieldOrder))
+		if !e.Equals(want) {
+			t.Errorf("Incorrect field element %d", i)
+		}
+	}
+	if u[0].Equals(u[1]) {
+		t.Error("Field elements weren't independent")
+	}
+}
+
+func TestMapToCurve(t *testing.T) {
+	for _, params := range []*CurveParams{JubjubParams(), BabyJubjubParams(), BandersnatchParams()} {
+		curve, _ := NewCurve(params)
+
+		if z := curve.elligator2Z(); z.n.Sign() == 0 || z != curve.elligator2Z() {
+			t.Fatalf("%s: no cached Elligator 2 constant", params.Name)
+		}
+
+		u, _ := curve.HashToField(sha512.New, []byte(params.Name), []byte("jubjub-test-map"), 8)
+		u = append(u, curve.newFieldElement(nil), curve.newFieldElement(nil).Set(curve.fieldOne))
+		for i, e := range u {
+			s, v := curve.elligator2(e)
+			if !curve.isOnMontgomery(s, v) {
+				t.Errorf("%s: Elligator 2 output %d isn't on the Montgomery curve", params.Name, i)
+			}
+
+			p := curve.MapToCurve(e)
+			if !p.IsOnCurve() {
+				t.Errorf("%s: map output %d isn't on the curve", params.Name, i)
+			}
+			if q := curve.MapToCurve(curve.newFieldElement(nil).Neg(e)); !q.Equals(p) {
+				t.Errorf("%s: map output %d depends on the sign of u", params.Name, i)
+			}
+		}
+	}
+}
+
+func TestSqrtCT(t *testing.T) {
+	for _, params := range []*CurveParams{JubjubParams(), EdBLS12377Params(), BabyJubjubParams()} {
+		curve, _ := NewCurve(params)
+
+		xs, _ := curve.HashToField(sha512.New, []byte(params.Name), []byte("jubjub-test-sqrt"), 8)
+		xs = append(xs, curve.newFieldElement(nil), curve.newFieldElement(nil).Set(curve.fieldOne))
+		for i, x := range xs {
+			want := curve.newFieldElement(nil).ModSqrt(x)
+			if sq := curve.isSquare(x); (want != nil) != (sq == 1) {
+				t.Errorf("%s: isSquare disagrees with ModSqrt on %d", params.Name, i)
+			}
+			if want == nil {
+				continue
+			}
+			have := curve.sqrtCT(x)
+			if !have.Equals(want) && !have.Equals(curve.newFieldElement(nil).Neg(want)) {
+				t.Errorf("%s: incorrect square root %d", params.Name, i)
+			}
+		}
+
+		if inv := curve.inv0(curve.newFieldElement(nil), curve.newFieldElement(nil)); !inv.Equals(curve.fieldZero) {
+			t.Errorf("%s: inv0(0) isn't 0", params.Name)
+		}
+	}
+}
+
+// There are no published RFC 9380 suites for Jubjub, so these are outputs of
+// this implementation, pinned to catch any change to the encoding. Points are
+// compressed and field elements are little-endian.
+var hashToCurveVectors = []struct {
+	msg           string
+	hashToCurve   string
+	encodeToCurve string
+}{
+	{"", "a3c6ee8106e08fa006c7b0b8c53b6081c028c0716474d9f8338943567f1531c5", "0566e35c8a9b3756b5ebefd1a6abd4bd8e85a2d27e514c9a8dc42c5440dc00c4"},
+	{"abc", "62050b0e6f759c1b1d5955425841887a8aebba007ca79a9e117551732b7c8e9a", "e31f6a846b7cbfa53490ca94bd25956accdf534e07960db332acd41915d53c62"},
+}
+
+var mapToCurveVectors = []struct {
+	u, p string
+}{
+	{"498b225485363820064c04fc100109bf73045e930bd805466a5c202208a56d3e", "01a4a2aeeb3e68cb1897e39d81e216085991e7988a680316dccc9791ac1bba50"},
+	{"c69ec1b0ee088b295a67678333ae77a30f39842662908dc1e4533aa6febea760", "547552aa7f19472e9949114f58085e34a78fbc9d43f0648ae39b06e1298bda5c"},
+}
+
+func TestHashToCurveVectors(t *testing.T) {
+	curve := Curve()
+	dst := []byte("jubjub-test-V01-CS01-with-jubjub_XMD:SHA-512_ELL2_RO_")
+
+	for i, tv := range hashToCurveVectors {
+		p, err := curve.HashToCurve(sha512.New, []byte(tv.msg), dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have := hex.EncodeToString(p.Compress()); have != tv.hashToCurve {
+			t.Errorf("Incorrect HashToCurve for test %d:\nWant: %s\nHave: %s", i, tv.hashToCurve, have)
+		}
+
+		p, err = curve.EncodeToCurve(sha512.New, []byte(tv.msg), dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have := hex.EncodeToString(p.Compress()); have != tv.encodeToCurve {
+			t.Errorf("Incorrect EncodeToCurve for test %d:\nWant: %s\nHave: %s", i, tv.encodeToCurve, have)
+		}
+	}
+
+	for i, tv := range mapToCurveVectors {
+		u, err := curve.FeFromCanonicalBytes(decodeHex(t, tv.u))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have := hex.EncodeToString(curve.MapToCurve(u).Compress()); have != tv.p {
+			t.Errorf("Incorrect MapToCurve for test %d:\nWant: %s\nHave: %s", i, tv.p, have)
+		}
+	}
+}
+
+func TestHashToCurve(t *testing.T) {
+	curve := Curve()
+	dst := []byte("jubjub-test-V01-CS01-with-jubjub_XMD:SHA-512_ELL2_RO_")
+
+	for _, hashFn := range []func(*Jubjub) (*Point, error){
+		func(c *Jubjub) (*Point, error) { return c.HashToCurve(sha512.New, []byte("abc"), dst) },
+		func(c *Jubjub) (*Point, error) { return c.EncodeToCurve(sha512.New, []byte("abc"), dst) },
+	} {
+		p, err := hashFn(curve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.IsOnCurve() || p.IsIdentity() || !p.inPrimeSubgroup() {
+			t.Error("Hash output isn't a nonzero point of the prime-order subgroup")
+		}
+		q, _ := hashFn(curve)
+		if !q.Equals(p) {
+			t.Error("Hash isn't deterministic")
+		}
+	}
+
+	p, _ := curve.HashToCurve(sha512.New, []byte("abc"), dst)
+	q, _ := curve.HashToCurve(sha512.New, []byte("abd"), dst)
+	r, _ := curve.HashToCurve(sha512.New, []byte("abc"), []byte("another DST"))
+	if p.Equals(q) || p.Equals(r) {
+		t.Error("Distinct inputs hashed to the same point")
+	}
+}
@@ -3,53 +3,48 @@ package jubjub
 
 import (
 	"math/big"
+	"sync"
 
 	"github.com/pkg/errors"
 )
 
 var (
-	ErrInvalidPoint error = errors.New("not a valid jubjub point")
-	ErrIdentity           = errors.New("point was in the h-torsion")
+	ErrInvalidPoint   error = errors.New("not a valid jubjub point")
+	ErrIdentity             = errors.New("point was in the h-torsion")
+	ErrLengthMismatch       = errors.New("scalars and points differ in length")
 )
 
-// Jubjub provides a context for working with the Jubjub elliptic curve.
+// Jubjub provides a context for working with a twisted Edwards curve. Curve
+// returns the Jubjub curve itself, and NewCurve builds a context for other
+// parameter sets.
 type Jubjub struct {
+	name          string
 	fieldOrder    *big.Int
 	subgroupOrder *big.Int
+	generatorX    *FieldElement
 	generatorY    *FieldElement
-	d             *FieldElement
+	a, d          *FieldElement
 	cofactor      *Scalar
 	fieldZero     *FieldElement
 	fieldOne      *FieldElement
 	fieldTwo      *FieldElement
+
+	// Lazily computed protocol generators, keyed by GroupHash input.
+	generatorsMu sync.Mutex
+	generators   map[string]*Point
+
+	// Elligator 2 and square root constants, computed on first use.
+	h2cOnce   sync.Once
+	h2cConsts *h2cConstants
+
+	// Roots of empty Sapling commitment subtrees, computed on first use.
+	emptyRootsOnce sync.Once
+	emptyRoots     [CommitmentTreeDepth + 1]MerkleNode
 }
 
-// Curve initializes a bunch of values needed for working with the Jubjub curve and returns a handle to that context.
+// Curve returns a handle to a context for the Jubjub curve.
 func Curve() *Jubjub {
-	fieldOrder, _ := new(big.Int).SetString("52435875175126190479447740508185965837690552500527637822603658699938581184513", 10)
-	subgroupOrder, _ := new(big.Int).SetString("6554484396890773809930967563523245729705921265872317281365359162392183254199", 10)
-
-	// d = -10240/10241
-	d := big.NewInt(10241)
-	d.ModInverse(d, fieldOrder)
-	d.Mul(d, big.NewInt(-10240))
-
-	// Used in formulas and comparisons, nice to have cached
-	feZero := newFieldElement(big.NewInt(0), fieldOrder)
-	feOne := newFieldElement(big.NewInt(1), fieldOrder)
-
-	h, _ := newScalar(big.NewInt(8), subgroupOrder)
-
-	jubjub := &Jubjub{
-		fieldOrder:    fieldOrder,
-		subgroupOrder: subgroupOrder,
-		generatorY:    newFieldElement(big.NewInt(11), fieldOrder),
-		d:             newFieldElement(d, fieldOrder),
-		cofactor:      h,
-		fieldZero:     feZero,
-		fieldOne:      feOne,
-	}
-	return jubjub
+	return newCurve(JubjubParams())
 }
 
 // Identity returns the curve's identity point
@@ -61,37 +56,93 @@ func (curve *Jubjub) Identity() *Point {
 	}
 }
 
-// Generator returns a generator for the full 8*q group on Jubjub, the positive point with y-value 11.
+// Generator returns the curve's generator. On Jubjub it generates the full 8*q
+// group and is the positive point with y-value 11.
 func (curve *Jubjub) Generator() *Point {
-	g, _ := curve.Decompress(curve.generatorY.ToBytes())
-	return g
+	return &Point{
+		curve,
+		curve.newFieldElement(nil).Set(curve.generatorX),
+		curve.newFieldElement(nil).Set(curve.generatorY),
+	}
 }
 
-// SubgroupGenerator returns a generator for the prime-order subgroup of Jubjub.
+// SubgroupGenerator returns a generator for the prime-order subgroup of the curve.
 func (curve *Jubjub) SubgroupGenerator() *Point {
 	return curve.Generator().MulByCofactor()
 }
 
 // ScalarMult multiplies the point by the scalar and returns a newly allocated result point.
-// It returns an error if the point is not on the curve.
+// It returns an error if the point is not on the curve, or if the curve's
+// addition law is incomplete and undefined for a step of the computation.
+//
+// The Montgomery ladder always runs for the bit length of the subgroup order and
+// swaps its registers without branching on the scalar, so the sequence of curve
+// operations doesn't depend on the secret. The underlying big.Int arithmetic
+// is still not constant time.
 func (curve *Jubjub) ScalarMult(scalar *Scalar, point *Point) (*Point, error) {
 	if !point.IsOnCurve() {
 		// TODO: is it worth having this check here instead of at callsites?
 		return nil, ErrInvalidPoint
 	}
 
-	r0, r1 := curve.Identity(), point.Clone()
-	for i := scalar.n.BitLen() - 1; i >= 0; i-- {
-		if scalar.n.Bit(i) == 0 {
-			r1.Add(r0, r1)
-			r0.Double(r0)
-		} else {
-			r0.Add(r0, r1)
-			r1.Double(r1)
+	result := curve.ladder(scalar.n, curve.subgroupOrder.BitLen(), point)
+	if !result.IsOnCurve() {
+		return nil, ErrInvalidPoint
+	}
+	return result, nil
+}
+
+// MultiScalarMult returns the sum of [scalars[i]] points[i] as a newly
+// allocated point. It returns ErrLengthMismatch if the slices differ in length
+// and ErrInvalidPoint if any point is not on the curve or, on curves with an
+// incomplete addition law, a step of the sum is undefined.
+//
+// The terms share one chain of doublings, so it costs little more than a
+// single ScalarMult, but it branches on the bits of the scalars and must only
+// be used with public values, as when checking proofs.
+func (curve *Jubjub) MultiScalarMult(scalars []*Scalar, points []*Point) (*Point, error) {
+	if len(scalars) != len(points) {
+		return nil, ErrLengthMismatch
+	}
+	for _, p := range points {
+		if !p.IsOnCurve() {
+			return nil, ErrInvalidPoint
 		}
 	}
 
-	return r0, nil
+	acc := curve.Identity()
+	for i := curve.subgroupOrder.BitLen() - 1; i >= 0; i-- {
+		acc.Double(acc)
+		for j, sc := range scalars {
+			if sc.n.Bit(i) == 1 {
+				acc.Add(acc, points[j])
+			}
+		}
+	}
+	if !acc.IsOnCurve() {
+		return nil, ErrInvalidPoint
+	}
+	return acc, nil
+}
+
+// ladder computes [k]point over the low bitLen bits of k and returns a newly allocated result point.
+func (curve *Jubjub) ladder(k *big.Int, bitLen int, point *Point) *Point {
+	r0, r1 := curve.Identity(), point.Clone()
+
+	// Invariant: r1 = r0 + point. Rather than choosing which register to
+	// double, swap them so that r0 is always the one doubled.
+	var swap uint
+	for i := bitLen - 1; i >= 0; i-- {
+		bit := k.Bit(i)
+		r0.condSwap(r1, swap^bit)
+		swap = bit
+
+		r1.Add(r0, r1)
+		r0.Double(r0)
+	}
+	r0.condSwap(r1, swap)
+
+	return r0
 }
 
 // Decompress reads a compressed Edwards point and returns that point or an error if it is invalid.
@@ -105,6 +156,52 @@ func (curve *Jubjub) Decompress(compressed []byte) (*Point, error) {
 	return p, nil
 }
 
+// DecompressBatch decompresses many encodings at once and returns a slice of
+// the same length, holding nil for each encoding that Decompress would reject.
+// The denominators of all of the x-coordinates are inverted together with
+// Montgomery's trick, so a batch costs one field inversion plus three
+// multiplications per point instead of one inversion per point. The square
+// roots are still taken one at a time.
+func (curve *Jubjub) DecompressBatch(compressed [][]byte) []*Point {
+	points := make([]*Point, len(compressed))
+	ys := make([]*FieldElement, len(compressed))
+	us := make([]*FieldElement, len(compressed))
+	signs := make([]byte, len(compressed))
+
+	// prefix[k] is the product of the first k valid denominators.
+	var vs []*FieldElement
+	var valid []int
+	prefix := []*FieldElement{curve.newFieldElement(nil).Set(curve.fieldOne)}
+	for i, enc := range compressed {
+		y, u, v, sign, err := curve.decompressStart(enc)
+		if err != nil {
+			continue
+		}
+		ys[i], us[i], signs[i] = y, u, sign
+		vs = append(vs, v)
+		valid = append(valid, i)
+		prefix = append(prefix, curve.newFieldElement(nil).Mul(prefix[len(prefix)-1], v))
+	}
+	if len(valid) == 0 {
+		return points
+	}
+
+	// Walk back from the inverse of the whole product, peeling off one
+	// denominator at a time.
+	acc := curve.newFieldElement(nil).ModInverse(prefix[len(valid)])
+	for k := len(valid) - 1; k >= 0; k-- {
+		vInv := curve.newFieldElement(nil).Mul(acc, prefix[k])
+		acc.Mul(acc, vs[k])
+
+		i := valid[k]
+		p := &Point{curve, curve.newFieldElement(nil), curve.newFieldElement(nil)}
+		if p.decompressFinish(ys[i], us[i], vInv, signs[i]) == nil {
+			points[i] = p
+		}
+	}
+	return points
+}
+
 // Point is a point on Jubjub.
 type Point struct {
 	curve *Jubjub
@@ -131,8 +228,8 @@ func (p *Point) Equals(q *Point) bool {
 
 // IsOnCurve returns true if the point is on the curve and false if not.
 func (p *Point) IsOnCurve() bool {
-	// a*x^2+y^2 = 1 + d*x^2*y^2, a = -1
-	//   => -x^2 + y^2 - 1 - d*x^2*y^2 = 0
+	// a*x^2+y^2 = 1 + d*x^2*y^2
+	//   => a*x^2 + y^2 - 1 - d*x^2*y^2 = 0
 
 	xx := newFieldElement(nil, p.curve.fieldOrder).Mul(p.x, p.x)
 	yy := newFieldElement(nil, p.curve.fieldOrder).Mul(p.y, p.y)
@@ -145,8 +242,8 @@ func (p *Point) IsOnCurve() bool {
 	// -1 - d*x^2*y^2
 	dxxyy.Neg(dxxyy)
 
-	// -x^2 + y^2 + (-1 - d*x^2*y^2) == 0
-	result := newFieldElement(nil, p.curve.fieldOrder).Neg(xx)
+	// a*x^2 + y^2 + (-1 - d*x^2*y^2) == 0
+	result := newFieldElement(nil, p.curve.fieldOrder).Mul(p.curve.a, xx)
 	result.Add(result, yy)
 	result.Add(result, dxxyy)
 
@@ -179,32 +276,60 @@ func (p *Point) MarshalBinary() ([]byte, error) {
 
 // UnmarshalBinary reads a Jubjub point in compressed Edwards y format and attempts to decompress it.
 func (p *Point) UnmarshalBinary(compressed []byte) error {
+	y, u, v, sign, err := p.curve.decompressStart(compressed)
+	if err != nil {
+		return err
+	}
+	return p.decompressFinish(y, u, v.ModInverse(v), sign)
+}
+
+// decompressStart parses an encoding and returns y, the sign bit of x, and the
+// numerator u = y^2 - 1 and denominator v = d*y^2 - a of x^2. Splitting
+// decompression around the inversion of v lets DecompressBatch share one
+// inversion between many points.
+func (curve *Jubjub) decompressStart(compressed []byte) (y, u, v *FieldElement, sign byte, err error) {
 	// TODO fixed length
 	// Recall that Jubjub is a slightly smaller curve, fits in 32
 	if len(compressed) != 32 {
-		return ErrInvalidPoint
+		return nil, nil, nil, 0, ErrInvalidPoint
 	}
 
-	fieldOne := p.curve.fieldOne
-	fieldOrder := p.curve.fieldOrder
+	fieldOne := curve.fieldOne
+	fieldOrder := curve.fieldOrder
 
 	// Extract & clear sign bit
 	// TODO fixed length
 	in := make([]byte, 32)
 	copy(in, compressed)
 
-	sign := in[31] >> 7
+	sign = in[31] >> 7
 	in[31] &= 0x7F
 
-	// We want to know sqrt((y^2 - 1) / (dy^2 + 1))
+	// abst_J also rejects encodings of y that are not reduced mod q.
+	y = newFieldElement(nil, fieldOrder).fromCanonicalBytes(in)
+	if y == nil {
+		return nil, nil, nil, 0, ErrInvalidPoint
+	}
+
+	// We want to know sqrt((y^2 - 1) / (dy^2 - a))
 
-	y := newFieldElement(nil, fieldOrder).fromBytes(in)
 	yy := newFieldElement(nil, fieldOrder).Mul(y, y)
-	v := newFieldElement(nil, fieldOrder)
-	u := newFieldElement(nil, fieldOrder).Sub(yy, fieldOne) // u = y^2 - 1
-	v.Mul(yy, p.curve.d).Add(v, fieldOne)                   // v = d*y^2 + 1
-	v.ModInverse(v)                                         // 1 / d*y^2 + 1
-	u.Mul(u, v)                                             // y^2 - 1 / d*y^2 + 1
+	v = newFieldElement(nil, fieldOrder)
+	u = newFieldElement(nil, fieldOrder).Sub(yy, fieldOne) // u = y^2 - 1
+	v.Mul(yy, curve.d).Sub(v, curve.a)                     // v = d*y^2 - a
+
+	// On curves where a/d is a square, such as Bandersnatch, v vanishes for
+	// values of y that don't belong to any affine point.
+	if v.Equals(curve.fieldZero) {
+		return nil, nil, nil, 0, ErrInvalidPoint
+	}
+	return y, u, v, sign, nil
+}
+
+// decompressFinish sets p to the point with the given y and sign bit, given
+// u and the inverse of v from decompressStart.
+func (p *Point) decompressFinish(y, u, vInv *FieldElement, sign byte) error {
+	u.Mul(u, vInv) // y^2 - 1 / d*y^2 - a
 
 	// 5.4.8.3 Jubjub
 	// When computing square roots in Fq in order to decompress a point encoding,
@@ -214,6 +339,11 @@ func (p *Point) UnmarshalBinary(compressed []byte) error {
 		return ErrInvalidPoint
 	}
 
+	// ZIP 216: x = 0 has only one valid encoding, the one with the sign bit clear.
+	if u.Equals(p.curve.fieldZero) && sign == 1 {
+		return ErrInvalidPoint
+	}
+
 	decompressed := u.ToBytes()[0] & 1
 	if sign != decompressed {
 		u.Neg(u)
@@ -229,6 +359,31 @@ func (p *Point) UnmarshalBinary(compressed []byte) error {
 	return nil
 }
 
+// IsInPrimeSubgroup reports whether p is in the prime-order subgroup of its
+// curve. Points decoded from untrusted input may have a small-order component,
+// which protocols that assume a prime-order group must reject. The check costs
+// a scalar multiplication.
+func (p *Point) IsInPrimeSubgroup() bool {
+	return p.inPrimeSubgroup()
+}
+
+// inPrimeSubgroup reports whether p is in the prime-order subgroup, that is, whether [r]p is the identity.
+func (p *Point) inPrimeSubgroup() bool {
+	// r itself isn't a valid Scalar, so run the ladder on it directly.
+	return p.IsOnCurve() && p.curve.ladder(p.curve.subgroupOrder, p.curve.subgroupOrder.BitLen(), p).IsIdentity()
+}
+
+// isSmallOrder reports whether p is in the h-torsion, that is, whether [h]p is the identity.
+func (p *Point) isSmallOrder() bool {
+	return p.Clone().MulByCofactor().IsIdentity()
+}
+
+// condSwap swaps p and q if b == 1 and leaves them unchanged if b == 0.
+func (p *Point) condSwap(q *Point, b uint) {
+	p.x.condSwap(q.x, b)
+	p.y.condSwap(q.y, b)
+}
+
 // Neg sets p to the negated form of q and returns p.
 func (p *Point) Neg(q *Point) *Point {
 	p.x.Neg(q.x)
@@ -238,7 +393,9 @@ func (p *Point) Neg(q *Point) *Point {
 
 // MulByCofactor sets p to the value of h*p and returns p.
 func (p *Point) MulByCofactor() *Point {
-	res, _ := p.curve.ScalarMult(p.curve.cofactor, p)
+	// The cofactor is public, so there's no need to run the full-length ladder.
+	h := p.curve.cofactor.n
+	res := p.curve.ladder(h, h.BitLen(), p)
 
 	p.x.Set(res.x)
 	p.y.Set(res.y)
@@ -246,12 +403,13 @@ func (p *Point) MulByCofactor() *Point {
 	return p
 }
 
-// Add adds p1+p2 and returns a newly allocated result point.
+// Add adds p1+p2 and returns a newly allocated result point. If the affine
+// addition law is undefined for p1 and p2, it returns the invalid point (0, 0)
+// described on (*Point).Add.
 func (curve *Jubjub) Add(p1 *Point, p2 *Point) *Point {
 	// Affine addition formulas: (x1,y1) + (x2,y2) = (x3,y3) where
 	//  x3 = (x1*y2 + y1*x2) / (1 + d*x1*x2*y1*y2)
 	//  y3 = (y1*y2 - a*x1*x2) / (1 - d*x1*x2*y1*y2)
-	// Recall a = -1
 
 	x1y2 := newFieldElement(nil, curve.fieldOrder).Mul(p1.x, p2.y)
 	x2y1 := newFieldElement(nil, curve.fieldOrder).Mul(p2.x, p1.y)
@@ -265,29 +423,35 @@ func (curve *Jubjub) Add(p1 *Point, p2 *Point) *Point {
 
 	// 1 / (1 + d*x1*x2*y1*y2)
 	tmp1 := newFieldElement(nil, curve.fieldOrder).Add(curve.fieldOne, commonTerm)
+	if tmp1.n.Sign() == 0 {
+		return curve.undefinedPoint()
+	}
 	tmp1.ModInverse(tmp1)
 
 	// 1 / (1 - d*x1*x2*y1*y1)
 	tmp2 := newFieldElement(nil, curve.fieldOrder).Sub(curve.fieldOne, commonTerm)
+	if tmp2.n.Sign() == 0 {
+		return curve.undefinedPoint()
+	}
 	tmp2.ModInverse(tmp2)
 
 	// x3 = (x1*y2 + x2*y1) / (1 + d*x1*x2*y1*y1)
 	x3 := newFieldElement(nil, curve.fieldOrder)
 	x3.Add(x1y2, x2y1).Mul(x3, tmp1)
 
-	// y3 = (y1*y2 + x1*x2) / (1 - d*x1*x2*y1*y1)
-	y3 := newFieldElement(nil, curve.fieldOrder)
-	y3.Add(y1y2, x1x2).Mul(y3, tmp2)
+	// y3 = (y1*y2 - a*x1*x2) / (1 - d*x1*x2*y1*y1)
+	y3 := newFieldElement(nil, curve.fieldOrder).Mul(curve.a, x1x2)
+	y3.Sub(y1y2, y3).Mul(y3, tmp2)
 
 	return &Point{curve, x3, y3}
 }
 
-// Double adds p1+p1 and returns a newly allocated result point.
+// Double adds p1+p1 and returns a newly allocated result point. If the affine
+// doubling law is undefined for p1, it returns the invalid point (0, 0).
 func (curve *Jubjub) Double(p1 *Point) *Point {
 	// Affine doubling formulas: 2(x1,y1) = (x3,y3) where
 	// x3 = (x1*y1 + y1*x1) / (1 + d*x1*x1*y1*y1)
 	// y3 = (y1*y1 - a*x1*x1) / (1 - d*x1*x1*y1*y1)
-	// Recall a = -1
 
 	x1x1 := newFieldElement(nil, curve.fieldOrder).Mul(p1.x, p1.x)
 	y1y1 := newFieldElement(nil, curve.fieldOrder).Mul(p1.y, p1.y)
@@ -299,6 +463,9 @@ func (curve *Jubjub) Double(p1 *Point) *Point {
 
 	// 1 / (1 + d*x1*x1*y1*y1)
 	tmp := newFieldElement(nil, curve.fieldOrder).Add(curve.fieldOne, commonTerm)
+	if tmp.n.Sign() == 0 {
+		return curve.undefinedPoint()
+	}
 	tmp.ModInverse(tmp)
 
 	// x3 = (x1*y1 + y1*x1) / (1 + d*x1*x1*y1*y1)
@@ -307,21 +474,42 @@ func (curve *Jubjub) Double(p1 *Point) *Point {
 
 	// 1 / (1 - d*x1*x1*y1*y1)
 	tmp.Sub(curve.fieldOne, commonTerm)
+	if tmp.n.Sign() == 0 {
+		return curve.undefinedPoint()
+	}
 	tmp.ModInverse(tmp)
 
 	// y3 = (y1*y1 - a*x1*x1) / (1 - d*x1*x1*y1*y1)
-	y3 := newFieldElement(nil, curve.fieldOrder)
-	y3.Add(y1y1, x1x1).Mul(y3, tmp)
+	y3 := newFieldElement(nil, curve.fieldOrder).Mul(curve.a, x1x1)
+	y3.Sub(y1y1, y3).Mul(y3, tmp)
 
 	return &Point{curve, x3, y3}
 }
 
+// undefinedPoint returns a newly allocated (0, 0), the result of an addition
+// that the affine formulas don't define.
+func (curve *Jubjub) undefinedPoint() *Point {
+	return &Point{curve, curve.newFieldElement(nil), curve.newFieldElement(nil)}
+}
+
+// setUndefined sets p to (0, 0) and returns p.
+func (p *Point) setUndefined() *Point {
+	p.x.n.SetInt64(0)
+	p.y.n.SetInt64(0)
+	return p
+}
+
 // Add sets p to the sum p1+p2 and returns p.
+//
+// On curves where the affine addition law is incomplete, such as Bandersnatch,
+// its denominators can vanish for points outside the prime-order subgroup. Add
+// then sets p to (0, 0), which is not on the curve and which Add and Double
+// map to itself, so the failure reaches the result of any longer computation.
+// ScalarMult and MultiScalarMult report it as ErrInvalidPoint.
 func (p *Point) Add(p1 *Point, p2 *Point) *Point {
 	// Affine addition formulas: (x1,y1) + (x2,y2) = (x3,y3) where
 	//  x3 = (x1*y2 + y1*x2) / (1 + d*x1*x2*y1*y2)
 	//  y3 = (y1*y2 - a*x1*x2) / (1 - d*x1*x2*y1*y2)
-	// Recall a = -1
 
 	x1y2 := newFieldElement(nil, p.curve.fieldOrder).Mul(p1.x, p2.y)
 	x2y1 := newFieldElement(nil, p.curve.fieldOrder).Mul(p2.x, p1.y)
@@ -333,29 +521,31 @@ func (p *Point) Add(p1 *Point, p2 *Point) *Point {
 	commonTerm := newFieldElement(nil, p.curve.fieldOrder).Mul(x1x2, y1y2)
 	commonTerm.Mul(commonTerm, p.curve.d)
 
-	// 1 / (1 + d*x1*x2*y1*y2)
-	tmp := newFieldElement(nil, p.curve.fieldOrder).Add(p.curve.fieldOne, commonTerm)
-	tmp.ModInverse(tmp)
+	// 1 / (1 + d*x1*x2*y1*y2) and 1 / (1 - d*x1*x2*y1*y2)
+	tmp1 := newFieldElement(nil, p.curve.fieldOrder).Add(p.curve.fieldOne, commonTerm)
+	tmp2 := newFieldElement(nil, p.curve.fieldOrder).Sub(p.curve.fieldOne, commonTerm)
+	if tmp1.n.Sign() == 0 || tmp2.n.Sign() == 0 {
+		return p.setUndefined()
+	}
+	tmp1.ModInverse(tmp1)
+	tmp2.ModInverse(tmp2)
 
 	// x3 = (x1*y2 + x2*y1) / (1 + d*x1*x2*y1*y1)
-	p.x.Add(x1y2, x2y1).Mul(p.x, tmp)
+	p.x.Add(x1y2, x2y1).Mul(p.x, tmp1)
 
-	// 1 / (1 - d*x1*x2*y1*y1)
-	tmp.Sub(p.curve.fieldOne, commonTerm)
-	tmp.ModInverse(tmp)
-
-	// y3 = (y1*y2 + x1*x2) / (1 - d*x1*x2*y1*y1)
-	p.y.Add(y1y2, x1x2).Mul(p.y, tmp)
+	// y3 = (y1*y2 - a*x1*x2) / (1 - d*x1*x2*y1*y1)
+	x1x2.Mul(p.curve.a, x1x2)
+	p.y.Sub(y1y2, x1x2).Mul(p.y, tmp2)
 
 	return p
 }
 
-// Double sets p to the sum p1+p1 and returns p.
+// Double sets p to the sum p1+p1 and returns p. Like Add, it sets p to (0, 0)
+// if the doubling law is undefined for p1.
 func (p *Point) Double(p1 *Point) *Point {
 	// Affine doubling formulas: 2(x1,y1) = (x3,y3) where
 	// x3 = (x1*y1 + y1*x1) / (1 + d*x1*x1*y1*y1)
 	// y3 = (y1*y1 - a*x1*x1) / (1 - d*x1*x1*y1*y1)
-	// Recall a = -1
 
 	x1x1 := newFieldElement(nil, p.curve.fieldOrder).Mul(p1.x, p1.x)
 	y1y1 := newFieldElement(nil, p.curve.fieldOrder).Mul(p1.y, p1.y)
@@ -365,19 +555,21 @@ func (p *Point) Double(p1 *Point) *Point {
 	commonTerm := newFieldElement(nil, p.curve.fieldOrder).Mul(x1x1, y1y1)
 	commonTerm.Mul(commonTerm, p.curve.d)
 
-	// 1 / (1 + d*x1*x1*y1*y1)
-	tmp := newFieldElement(nil, p.curve.fieldOrder).Add(p.curve.fieldOne, commonTerm)
-	tmp.ModInverse(tmp)
+	// 1 / (1 + d*x1*x1*y1*y1) and 1 / (1 - d*x1*x1*y1*y1)
+	tmp1 := newFieldElement(nil, p.curve.fieldOrder).Add(p.curve.fieldOne, commonTerm)
+	tmp2 := newFieldElement(nil, p.curve.fieldOrder).Sub(p.curve.fieldOne, commonTerm)
+	if tmp1.n.Sign() == 0 || tmp2.n.Sign() == 0 {
+		return p.setUndefined()
+	}
+	tmp1.ModInverse(tmp1)
+	tmp2.ModInverse(tmp2)
 
 	// x3 = (x1*y1 + y1*x1) / (1 + d*x1*x1*y1*y1)
-	p.x.Add(x1y1, x1y1).Mul(p.x, tmp)
-
-	// 1 / (1 - d*x1*x1*y1*y1)
-	tmp.Sub(p.curve.fieldOne, commonTerm)
-	tmp.ModInverse(tmp)
+	p.x.Add(x1y1, x1y1).Mul(p.x, tmp1)
 
 	// y3 = (y1*y1 - a*x1*x1) / (1 - d*x1*x1*y1*y1)
-	p.y.Add(y1y1, x1x1).Mul(p.y, tmp)
+	x1x1.Mul(p.curve.a, x1x1)
+	p.y.Sub(y1y1, x1x1).Mul(p.y, tmp2)
 
 	return p
 }
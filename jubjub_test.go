@@ -73,13 +73,18 @@ func TestGeneratorBehavior(t *testing.T) {
 	// Multiplying the generator by the order of the group should yield the identity point.
 	subgroupOrder, _ := new(big.Int).SetString("6554484396890773809930967563523245729705921265872317281365359162392183254199", 10)
 
-	scalar, err := curve.ScalarFromBig(subgroupOrder)
+	// The order itself is out of range for a scalar, so compute [r-1]G + G.
+	if _, err := curve.ScalarFromBig(new(big.Int).Set(subgroupOrder)); err != ErrScalarOutOfRange {
+		t.Error("accepted the subgroup order as a scalar")
+	}
+	scalar, err := curve.ScalarFromBig(subgroupOrder.Sub(subgroupOrder, big.NewInt(1)))
 	if err != nil {
-		t.Error("didn't like subgroup order scalar")
+		t.Error("didn't like subgroup order minus one scalar")
 	}
 
 	identity, _ := curve.ScalarMult(scalar, subgroup)
-	if !identity.Equals(curve.Identity()) {
+	identity.Add(identity, subgroup)
+	if !identity.Equals(curve.Identity()) || !subgroup.IsInPrimeSubgroup() {
 		t.Fatal("q*8*G != (0, 1)")
 	}
 }
@@ -103,6 +108,42 @@ func BenchmarkScalarMult(b *testing.B) {
 	}
 }
 
+func TestMultiScalarMult(t *testing.T) {
+	curve := Curve()
+
+	var scalars []*Scalar
+	var points []*Point
+	want := curve.Identity()
+	for i := 0; i < 4; i++ {
+		sc, _ := curve.RandomScalar(nil)
+		p, _ := curve.RandomSubgroupPoint(nil)
+		if i == 3 {
+			// A point outside the prime-order subgroup.
+			p = curve.Generator()
+		}
+		scalars = append(scalars, sc)
+		points = append(points, p)
+
+		term, _ := curve.ScalarMult(sc, p)
+		want.Add(want, term)
+	}
+
+	have, err := curve.MultiScalarMult(scalars, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !have.Equals(want) {
+		t.Error("Multi-scalar multiplication disagrees with ScalarMult")
+	}
+
+	if p, _ := curve.MultiScalarMult(nil, nil); !p.IsIdentity() {
+		t.Error("Empty sum wasn't the identity")
+	}
+	if _, err := curve.MultiScalarMult(scalars[:1], points); err != ErrLengthMismatch {
+		t.Error("Accepted slices of different lengths")
+	}
+}
+
 func TestPointClone(t *testing.T) {
 	curve := Curve()
 	g := curve.Generator()
@@ -268,3 +309,62 @@ func TestBasicProperties(t *testing.T) {
 		}
 	}
 }
+
+func TestNonCanonicalEncodings(t *testing.T) {
+	curve := Curve()
+
+	// The generator's y-coordinate plus the field order still fits in 255 bits.
+	y := new(big.Int).Add(big.NewInt(11), curve.fieldOrder)
+	nonCanonical := make([]byte, 32)
+	for i, b := range y.Bytes() {
+		nonCanonical[len(y.Bytes())-1-i] = b
+	}
+	if _, err := curve.Decompress(nonCanonical); err != ErrInvalidPoint {
+		t.Error("Accepted a y-coordinate that was not reduced")
+	}
+
+	// The point (0, -1) must not be encoded with the sign bit set.
+	minusOne := curve.newFieldElement(nil).Sub(curve.fieldZero, curve.fieldOne).ToBytes()
+	if _, err := curve.Decompress(minusOne); err != nil {
+		t.Fatal(err)
+	}
+	minusOne[31] |= 0x80
+	if _, err := curve.Decompress(minusOne); err != ErrInvalidPoint {
+		t.Error("Accepted a negative zero x-coordinate")
+	}
+}
+
+func TestDecompressBatch(t *testing.T) {
+	curve := Curve()
+
+	encs := [][]byte{curve.Generator().Compress()}
+	for i := 0; i < 4; i++ {
+		p, _ := curve.RandomSubgroupPoint(nil)
+		encs = append(encs, p.Compress())
+	}
+	// There is no x with y = 2 on Jubjub.
+	notOnCurve := make([]byte, 32)
+	notOnCurve[0] = 2
+	minusOne := curve.newFieldElement(nil).Sub(curve.fieldZero, curve.fieldOne).ToBytes()
+	negativeZero := append([]byte{}, minusOne...)
+	negativeZero[31] |= 0x80
+	encs = append(encs[:2], append([][]byte{notOnCurve, minusOne, negativeZero, encs[0][:31], bytes.Repeat([]byte{0xff}, 32)}, encs[2:]...)...)
+
+	points := curve.DecompressBatch(encs)
+	if len(points) != len(encs) {
+		t.Fatalf("Got %d points for %d encodings", len(points), len(encs))
+	}
+	for i, enc := range encs {
+		want, err := curve.Decompress(enc)
+		switch {
+		case err != nil && points[i] != nil:
+			t.Errorf("Batch accepted encoding %d, which Decompress rejects", i)
+		case err == nil && (points[i] == nil || !points[i].Equals(want)):
+			t.Errorf("Batch disagrees with Decompress on encoding %d", i)
+		}
+	}
+
+	if points := curve.DecompressBatch(nil); len(points) != 0 {
+		t.Error("Decompressed points from an empty batch")
+	}
+}
@@ -0,0 +1,40 @@
+package jubjub
+
+// KADerivePublic computes the Sapling key agreement public key [esk] g_d and
+// returns a newly allocated result point.
+func (curve *Jubjub) KADerivePublic(esk *Scalar, gd *Point) (*Point, error) {
+	return curve.ScalarMult(esk, gd)
+}
+
+// KAAgree computes the Sapling key agreement shared secret [h][sk] pk, where sk
+// is ivk or esk and pk is epk or pk_d respectively, and returns a newly
+// allocated result point. It returns ErrIdentity if pk has small order, since
+// the shared secret would then be the identity regardless of sk.
+func (curve *Jubjub) KAAgree(sk *Scalar, pk *Point) (*Point, error) {
+	if err := kaValidatePublic(pk); err != nil {
+		return nil, err
+	}
+	return curve.kaAgree(sk, pk)
+}
+
+// kaValidatePublic performs the checks KAAgree makes on pk, so that callers
+// agreeing on the same pk with many secret keys only have to make them once.
+func kaValidatePublic(pk *Point) error {
+	if !pk.IsOnCurve() {
+		return ErrInvalidPoint
+	}
+	if pk.Clone().MulByCofactor().IsIdentity() {
+		return ErrIdentity
+	}
+	return nil
+}
+
+// kaAgree is KAAgree for a pk that has already passed kaValidatePublic.
+func (curve *Jubjub) kaAgree(sk *Scalar, pk *Point) (*Point, error) {
+	shared, err := curve.ScalarMult(sk, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	return shared.MulByCofactor(), nil
+}
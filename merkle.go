@@ -0,0 +1,513 @@
+package jubjub
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrTreeFull            = errors.New("commitment tree is full")
+	ErrEmptyTree           = errors.New("commitment tree is empty")
+	ErrInvalidTreeEncoding = errors.New("not a valid commitment tree encoding")
+)
+
+// CommitmentTreeDepth is the depth of the Sapling note commitment tree.
+const CommitmentTreeDepth = 32
+
+// MerkleNode is a node of the Sapling note commitment tree: the little-endian
+// encoding of a u-coordinate, as returned by ExtractJ.
+type MerkleNode [32]byte
+
+// MerkleCRH hashes two children at the given layer, counted up from the leaves,
+// as ExtractJ(PedersenHash("Zcash_PH", I2LEBSP_6(layer) || left || right))
+// where each child contributes the low 255 bits of its encoding.
+func (curve *Jubjub) MerkleCRH(layer int, left, right MerkleNode) MerkleNode {
+	msg := make([]bool, 0, 6+2*255)
+	for i := uint(0); i < 6; i++ {
+		msg = append(msg, (layer>>i)&1 == 1)
+	}
+	msg = append(msg, bytesToBits(left[:])[:255]...)
+	msg = append(msg, bytesToBits(right[:])[:255]...)
+
+	var out MerkleNode
+	copy(out[:], curve.PedersenHashToPoint("Zcash_PH", msg).ExtractJ())
+	return out
+}
+
+// EmptyRoot returns the root of an empty subtree of the given height. Unused
+// leaves hold the uncommitted value 1.
+func (curve *Jubjub) EmptyRoot(height int) MerkleNode {
+	curve.emptyRootsOnce.Do(func() {
+		curve.emptyRoots[0][0] = 1
+		for i := 0; i < CommitmentTreeDepth; i++ {
+			curve.emptyRoots[i+1] = curve.MerkleCRH(i, curve.emptyRoots[i], curve.emptyRoots[i])
+		}
+	})
+	return curve.emptyRoots[height]
+}
+
+// pathFiller supplies the nodes missing from a tree's frontier, taking them
+// from queue first and then using empty subtree roots.
+type pathFiller struct {
+	curve *Jubjub
+	queue []MerkleNode
+}
+
+func (f *pathFiller) next(height int) MerkleNode {
+	if len(f.queue) > 0 {
+		n := f.queue[0]
+		f.queue = f.queue[1:]
+		return n
+	}
+	return f.curve.EmptyRoot(height)
+}
+
+// CommitmentTree is the frontier of an append-only Sapling note commitment
+// tree, laid out as in zcashd: the two most recent leaves, followed by the
+// roots of the completed left subtrees at each height above them.
+type CommitmentTree struct {
+	curve *Jubjub
+
+	left, right *MerkleNode
+	parents     []*MerkleNode
+}
+
+// NewCommitmentTree returns an empty tree.
+func (curve *Jubjub) NewCommitmentTree() *CommitmentTree {
+	return &CommitmentTree{curve: curve}
+}
+
+// clone returns a copy of the tree. Nodes are never modified in place, so they
+// can be shared.
+func (t *CommitmentTree) clone() *CommitmentTree {
+	c := *t
+	c.parents = append([]*MerkleNode{}, t.parents...)
+	return &c
+}
+
+// Size returns the number of leaves appended to the tree.
+func (t *CommitmentTree) Size() uint64 {
+	var size uint64
+	if t.left != nil {
+		size++
+	}
+	if t.right != nil {
+		size++
+	}
+	for i, p := range t.parents {
+		if p != nil {
+			size += 1 << uint(i+1)
+		}
+	}
+	return size
+}
+
+// isComplete reports whether the tree holds 2^depth leaves.
+func (t *CommitmentTree) isComplete(depth int) bool {
+	if t.left == nil || t.right == nil || len(t.parents) != depth-1 {
+		return false
+	}
+	for _, p := range t.parents {
+		if p == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// nextDepth returns the height of the subtree that will be completed next
+// after skipping the first skip incomplete ones.
+func (t *CommitmentTree) nextDepth(skip int) int {
+	if t.left == nil {
+		if skip == 0 {
+			return 0
+		}
+		skip--
+	}
+	if t.right == nil {
+		if skip == 0 {
+			return 0
+		}
+		skip--
+	}
+
+	d := 1
+	for _, p := range t.parents {
+		if p == nil {
+			if skip == 0 {
+				return d
+			}
+			skip--
+		}
+		d++
+	}
+	return d + skip
+}
+
+// Append adds a leaf to the tree.
+func (t *CommitmentTree) Append(node MerkleNode) error {
+	if t.isComplete(CommitmentTreeDepth) {
+		return ErrTreeFull
+	}
+
+	switch {
+	case t.left == nil:
+		t.left = &node
+	case t.right == nil:
+		t.right = &node
+	default:
+		combined := t.curve.MerkleCRH(0, *t.left, *t.right)
+		t.left, t.right = &node, nil
+
+		for i, p := range t.parents {
+			if p == nil {
+				t.parents[i] = &combined
+				return nil
+			}
+			combined = t.curve.MerkleCRH(i+1, *p, combined)
+			t.parents[i] = nil
+		}
+		t.parents = append(t.parents, &combined)
+	}
+	return nil
+}
+
+// Root returns the root of the depth-32 tree, the anchor of a Sapling spend.
+func (t *CommitmentTree) Root() MerkleNode {
+	return t.root(CommitmentTreeDepth, nil)
+}
+
+// root computes the root of a tree of the given depth, taking missing nodes
+// from filler.
+func (t *CommitmentTree) root(depth int, filler []MerkleNode) MerkleNode {
+	f := &pathFiller{t.curve, filler}
+
+	var left, right MerkleNode
+	if t.left != nil {
+		left = *t.left
+	} else {
+		left = f.next(0)
+	}
+	if t.right != nil {
+		right = *t.right
+	} else {
+		right = f.next(0)
+	}
+	root := t.curve.MerkleCRH(0, left, right)
+
+	d := 1
+	for _, p := range t.parents {
+		if p != nil {
+			root = t.curve.MerkleCRH(d, *p, root)
+		} else {
+			root = t.curve.MerkleCRH(d, root, f.next(d))
+		}
+		d++
+	}
+	for ; d < depth; d++ {
+		root = t.curve.MerkleCRH(d, root, f.next(d))
+	}
+	return root
+}
+
+// path returns the authentication path of the most recently appended leaf,
+// taking missing nodes from filler.
+func (t *CommitmentTree) path(filler []MerkleNode) (*MerklePath, error) {
+	if t.left == nil {
+		return nil, ErrEmptyTree
+	}
+	f := &pathFiller{t.curve, filler}
+	path := &MerklePath{curve: t.curve, Position: t.Size() - 1}
+
+	if t.right != nil {
+		path.AuthPath[0] = *t.left
+	} else {
+		path.AuthPath[0] = f.next(0)
+	}
+
+	d := 1
+	for _, p := range t.parents {
+		if p != nil {
+			path.AuthPath[d] = *p
+		} else {
+			path.AuthPath[d] = f.next(d)
+		}
+		d++
+	}
+	for ; d < CommitmentTreeDepth; d++ {
+		path.AuthPath[d] = f.next(d)
+	}
+	return path, nil
+}
+
+// Witness returns a witness for the most recently appended leaf, which is
+// kept up to date by appending every later leaf to it.
+func (t *CommitmentTree) Witness() (*IncrementalWitness, error) {
+	if t.left == nil {
+		return nil, ErrEmptyTree
+	}
+	return &IncrementalWitness{tree: t.clone()}, nil
+}
+
+// MarshalBinary encodes the tree in zcashd's format: Optional(left) ||
+// Optional(right) || CompactSize(len(parents)) || Optional(parent)*, where an
+// Optional is either 0x00 or 0x01 followed by the node.
+func (t *CommitmentTree) MarshalBinary() ([]byte, error) {
+	return t.appendBinary(nil), nil
+}
+
+func (t *CommitmentTree) appendBinary(out []byte) []byte {
+	out = appendOptionalNode(out, t.left)
+	out = appendOptionalNode(out, t.right)
+	out = appendCompactSize(out, uint64(len(t.parents)))
+	for _, p := range t.parents {
+		out = appendOptionalNode(out, p)
+	}
+	return out
+}
+
+// CommitmentTreeFromBytes decodes a tree in zcashd's format. Every node must be
+// a canonically encoded field element.
+func (curve *Jubjub) CommitmentTreeFromBytes(in []byte) (*CommitmentTree, error) {
+	t, rest, err := curve.readCommitmentTree(in)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrInvalidTreeEncoding
+	}
+	return t, nil
+}
+
+func (curve *Jubjub) readCommitmentTree(in []byte) (*CommitmentTree, []byte, error) {
+	t := curve.NewCommitmentTree()
+
+	var err error
+	if t.left, in, err = curve.readOptionalNode(in); err != nil {
+		return nil, nil, err
+	}
+	if t.right, in, err = curve.readOptionalNode(in); err != nil {
+		return nil, nil, err
+	}
+	n, in, err := readCompactSize(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n >= CommitmentTreeDepth {
+		return nil, nil, ErrInvalidTreeEncoding
+	}
+	t.parents = make([]*MerkleNode, n)
+	for i := range t.parents {
+		if t.parents[i], in, err = curve.readOptionalNode(in); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Same well-formedness rules as zcashd.
+	if t.left == nil && t.right != nil {
+		return nil, nil, ErrInvalidTreeEncoding
+	}
+	if t.left == nil && n > 0 {
+		return nil, nil, ErrInvalidTreeEncoding
+	}
+	if n > 0 && t.parents[n-1] == nil {
+		return nil, nil, ErrInvalidTreeEncoding
+	}
+	return t, in, nil
+}
+
+func appendOptionalNode(out []byte, n *MerkleNode) []byte {
+	if n == nil {
+		return append(out, 0)
+	}
+	out = append(out, 1)
+	return append(out, n[:]...)
+}
+
+func (curve *Jubjub) readOptionalNode(in []byte) (*MerkleNode, []byte, error) {
+	if len(in) == 0 {
+		return nil, nil, ErrInvalidTreeEncoding
+	}
+	switch in[0] {
+	case 0:
+		return nil, in[1:], nil
+	case 1:
+		n, err := curve.readNode(in[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		return n, in[33:], nil
+	default:
+		return nil, nil, ErrInvalidTreeEncoding
+	}
+}
+
+func (curve *Jubjub) readNode(in []byte) (*MerkleNode, error) {
+	if len(in) < 32 {
+		return nil, ErrInvalidTreeEncoding
+	}
+	if _, err := curve.FeFromCanonicalBytes(in[:32]); err != nil {
+		return nil, err
+	}
+	n := new(MerkleNode)
+	copy(n[:], in)
+	return n, nil
+}
+
+// MerklePath is the authentication path of a leaf in the commitment tree.
+type MerklePath struct {
+	curve *Jubjub
+
+	// AuthPath holds the sibling at each height, starting from the leaf.
+	AuthPath [CommitmentTreeDepth]MerkleNode
+	Position uint64
+}
+
+// Root returns the root of the tree that contains leaf at the path's position.
+func (p *MerklePath) Root(leaf MerkleNode) MerkleNode {
+	curve := p.curve
+	if curve == nil {
+		curve = Curve()
+	}
+
+	node := leaf
+	for i, sibling := range p.AuthPath {
+		if (p.Position>>uint(i))&1 == 1 {
+			node = curve.MerkleCRH(i, sibling, node)
+		} else {
+			node = curve.MerkleCRH(i, node, sibling)
+		}
+	}
+	return node
+}
+
+// IncrementalWitness tracks the authentication path of one leaf as the tree
+// grows. It keeps the tree as it was when the leaf was appended, the roots of
+// the subtrees to its right that have since been filled, and a cursor tree
+// holding the subtree currently being filled.
+type IncrementalWitness struct {
+	tree        *CommitmentTree
+	filled      []MerkleNode
+	cursor      *CommitmentTree
+	cursorDepth int
+}
+
+// Position returns the index of the witnessed leaf.
+func (w *IncrementalWitness) Position() uint64 {
+	return w.tree.Size() - 1
+}
+
+// Leaf returns the witnessed leaf.
+func (w *IncrementalWitness) Leaf() MerkleNode {
+	if w.tree.right != nil {
+		return *w.tree.right
+	}
+	return *w.tree.left
+}
+
+// Append adds the next leaf of the tree to the witness.
+func (w *IncrementalWitness) Append(node MerkleNode) error {
+	if w.cursor != nil {
+		if err := w.cursor.Append(node); err != nil {
+			return err
+		}
+		if w.cursor.isComplete(w.cursorDepth) {
+			w.filled = append(w.filled, w.cursor.root(w.cursorDepth, nil))
+			w.cursor = nil
+		}
+		return nil
+	}
+
+	w.cursorDepth = w.tree.nextDepth(len(w.filled))
+	if w.cursorDepth >= CommitmentTreeDepth {
+		return ErrTreeFull
+	}
+	if w.cursorDepth == 0 {
+		w.filled = append(w.filled, node)
+		return nil
+	}
+	w.cursor = w.tree.curve.NewCommitmentTree()
+	return w.cursor.Append(node)
+}
+
+// partialPath returns the filled subtree roots followed by the root of the
+// cursor, if any.
+func (w *IncrementalWitness) partialPath() []MerkleNode {
+	path := append([]MerkleNode{}, w.filled...)
+	if w.cursor != nil {
+		path = append(path, w.cursor.root(w.cursorDepth, nil))
+	}
+	return path
+}
+
+// Root returns the root of the current tree.
+func (w *IncrementalWitness) Root() MerkleNode {
+	return w.tree.root(CommitmentTreeDepth, w.partialPath())
+}
+
+// Path returns the authentication path of the witnessed leaf in the current tree.
+func (w *IncrementalWitness) Path() (*MerklePath, error) {
+	return w.tree.path(w.partialPath())
+}
+
+// MarshalBinary encodes the witness in zcashd's format: tree ||
+// CompactSize(len(filled)) || filled || Optional(cursor).
+func (w *IncrementalWitness) MarshalBinary() ([]byte, error) {
+	out := w.tree.appendBinary(nil)
+	out = appendCompactSize(out, uint64(len(w.filled)))
+	for _, n := range w.filled {
+		out = append(out, n[:]...)
+	}
+	if w.cursor == nil {
+		return append(out, 0), nil
+	}
+	out = append(out, 1)
+	return w.cursor.appendBinary(out), nil
+}
+
+// IncrementalWitnessFromBytes decodes a witness in zcashd's format.
+func (curve *Jubjub) IncrementalWitnessFromBytes(in []byte) (*IncrementalWitness, error) {
+	tree, in, err := curve.readCommitmentTree(in)
+	if err != nil {
+		return nil, err
+	}
+	if tree.left == nil {
+		return nil, ErrEmptyTree
+	}
+	w := &IncrementalWitness{tree: tree}
+
+	n, in, err := readCompactSize(in)
+	if err != nil {
+		return nil, err
+	}
+	if n >= CommitmentTreeDepth {
+		return nil, ErrInvalidTreeEncoding
+	}
+	for i := uint64(0); i < n; i++ {
+		node, err := curve.readNode(in)
+		if err != nil {
+			return nil, err
+		}
+		w.filled = append(w.filled, *node)
+		in = in[32:]
+	}
+
+	if len(in) == 0 {
+		return nil, ErrInvalidTreeEncoding
+	}
+	switch in[0] {
+	case 0:
+		in = in[1:]
+	case 1:
+		if w.cursor, in, err = curve.readCommitmentTree(in[1:]); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrInvalidTreeEncoding
+	}
+	if len(in) != 0 {
+		return nil, ErrInvalidTreeEncoding
+	}
+
+	w.cursorDepth = tree.nextDepth(len(w.filled))
+	return w, nil
+}
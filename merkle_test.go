@@ -0,0 +1,165 @@
+package jubjub
+
+import (
+	"bytes"
+	"testing"
+)
+
+// hexNode decodes a node written in the byte-reversed order that zcashd and the
+// reference implementation display.
+func hexNode(t *testing.T, s string) MerkleNode {
+	b := decodeHex(t, s)
+	var n MerkleNode
+	for i := range n {
+		n[i] = b[len(b)-1-i]
+	}
+	return n
+}
+
+func TestMerkleCRH(t *testing.T) {
+	curve := Curve()
+
+	// https://github.com/zcash/zcash-test-vectors/blob/master/zcash_test_vectors/sapling/merkle_tree.py
+	a := hexNode(t, "87a086ae7d2252d58729b30263fb7b66308bf94ef59a76c9c86e7ea016536505")
+	b := hexNode(t, "a75b84a125b2353da7e8d96ee2a15efe4de23df9601b9d9564ba59de57130406")
+	want := hexNode(t, "5bf43b5736c19b714d1f462c9d22ba3492c36e3d9bbd7ca24d94b440550aa561")
+	if have := curve.MerkleCRH(25, a, b); have != want {
+		t.Errorf("Incorrect MerkleCRH:\nWant: %x\nHave: %x", want, have)
+	}
+
+	// The root of the empty Sapling tree, as zcashd displays it.
+	want = hexNode(t, "3e49b5f954aa9d3545bc6c37744661eea48d7c34e3000d82b7f0010c30f4c2fb")
+	if have := curve.NewCommitmentTree().Root(); have != want {
+		t.Errorf("Incorrect empty root:\nWant: %x\nHave: %x", want, have)
+	}
+}
+
+// naiveRoot hashes a list of leaves up to the depth-32 root, one layer at a time.
+func naiveRoot(curve *Jubjub, leaves []MerkleNode) MerkleNode {
+	layer := append([]MerkleNode{}, leaves...)
+	for h := 0; h < CommitmentTreeDepth; h++ {
+		if len(layer) == 0 {
+			return curve.EmptyRoot(CommitmentTreeDepth)
+		}
+		if len(layer)%2 == 1 {
+			layer = append(layer, curve.EmptyRoot(h))
+		}
+		next := make([]MerkleNode, 0, len(layer)/2)
+		for i := 0; i < len(layer); i += 2 {
+			next = append(next, curve.MerkleCRH(h, layer[i], layer[i+1]))
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+func TestCommitmentTree(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping commitment tree test in short mode")
+	}
+
+	curve := Curve()
+	tree := curve.NewCommitmentTree()
+
+	if _, err := tree.Witness(); err != ErrEmptyTree {
+		t.Errorf("Witnessed an empty tree: %v", err)
+	}
+	if enc, _ := tree.MarshalBinary(); !bytes.Equal(enc, []byte{0, 0, 0}) {
+		t.Errorf("Incorrect empty tree encoding: %x", enc)
+	}
+
+	// Five leaves exercise filled subtrees as well as complete and partial
+	// cursors. Each root costs 32 Pedersen hashes, so witnesses are checked
+	// once the tree is built.
+	var leaves []MerkleNode
+	var witnesses []*IncrementalWitness
+	for i := 0; i < 5; i++ {
+		leaf := MerkleNode{byte(i + 2)}
+		if err := tree.Append(leaf); err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range witnesses {
+			if err := w.Append(leaf); err != nil {
+				t.Fatal(err)
+			}
+		}
+		leaves = append(leaves, leaf)
+
+		w, err := tree.Witness()
+		if err != nil {
+			t.Fatal(err)
+		}
+		witnesses = append(witnesses, w)
+	}
+
+	root := tree.Root()
+	if want := naiveRoot(curve, leaves); root != want {
+		t.Fatalf("Incorrect root:\nWant: %x\nHave: %x", want, root)
+	}
+	if tree.Size() != uint64(len(leaves)) {
+		t.Errorf("Incorrect size: %d", tree.Size())
+	}
+	if witnesses[0].Root() != root {
+		t.Error("Incorrect witness root")
+	}
+
+	enc, _ := tree.MarshalBinary()
+	decoded, err := curve.CommitmentTreeFromBytes(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reenc, _ := decoded.MarshalBinary(); !bytes.Equal(reenc, enc) {
+		t.Error("Tree didn't roundtrip")
+	}
+
+	for i, w := range witnesses {
+		if w.Position() != uint64(i) || w.Leaf() != leaves[i] {
+			t.Errorf("Witness %d tracks the wrong leaf", i)
+		}
+		path, err := w.Path()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path.Position != uint64(i) || path.Root(leaves[i]) != root {
+			t.Errorf("Incorrect path for witness %d", i)
+		}
+
+		enc, _ := w.MarshalBinary()
+		decoded, err := curve.IncrementalWitnessFromBytes(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reenc, _ := decoded.MarshalBinary(); !bytes.Equal(reenc, enc) {
+			t.Errorf("Witness %d didn't roundtrip", i)
+		}
+	}
+}
+
+func TestCommitmentTreeEncoding(t *testing.T) {
+	curve := Curve()
+
+	leaf := append([]byte{1}, make([]byte, 32)...)
+	leaf[1] = 7
+	for i, enc := range [][]byte{
+		{},
+		{0, 0},
+		{2, 0, 0},
+		append(append([]byte{0}, leaf...), 0), // right without left
+		append([]byte{0, 0, 1}, leaf...),      // parents without left
+		append(append([]byte{}, leaf...), 0, 1, 0),                         // last parent empty
+		append(append([]byte{}, leaf...), 0, 0, 0),                         // trailing data
+		append(append([]byte{1}, bytes.Repeat([]byte{0xff}, 32)...), 0, 0), // non-canonical
+	} {
+		if _, err := curve.CommitmentTreeFromBytes(enc); err == nil {
+			t.Errorf("Accepted invalid tree encoding %d", i)
+		}
+	}
+
+	tree, err := curve.CommitmentTreeFromBytes(append(leaf, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Size() != 1 {
+		t.Errorf("Incorrect size for decoded tree: %d", tree.Size())
+	}
+}
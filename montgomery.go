@@ -0,0 +1,205 @@
+package jubjub
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrMontgomeryInfinity = errors.New("point maps to the Montgomery point at infinity")
+)
+
+// A twisted Edwards curve a*x^2 + y^2 = 1 + d*x^2*y^2 is birationally
+// equivalent to the Montgomery curve B*v^2 = u^3 + A*u^2 + u with
+//  A = 2*(a + d) / (a - d), B = 4 / (a - d)
+// under the maps
+//  (u, v) = ((1 + y) / (1 - y), u / x)
+//  (x, y) = (u / v, (u - 1) / (u + 1))
+// For Jubjub, A = 40962 and B = -40964.
+
+// MontgomeryParams returns the coefficients A and B of the curve's Montgomery form.
+func (curve *Jubjub) MontgomeryParams() (A, B *FieldElement) {
+	aMinusD := curve.newFieldElement(nil).Sub(curve.a, curve.d)
+	inv := curve.newFieldElement(nil).ModInverse(aMinusD)
+
+	A = curve.newFieldElement(nil).Add(curve.a, curve.d)
+	A.Add(A, A).Mul(A, inv)
+
+	B = curve.newFieldElement(big.NewInt(4))
+	B.Mul(B, inv)
+	return A, B
+}
+
+// isOnMontgomery reports whether (u, v) satisfies B*v^2 = u^3 + A*u^2 + u.
+func (curve *Jubjub) isOnMontgomery(u, v *FieldElement) bool {
+	A, B := curve.MontgomeryParams()
+
+	lhs := curve.newFieldElement(nil).Mul(v, v)
+	lhs.Mul(lhs, B)
+
+	rhs := curve.newFieldElement(nil).Add(u, A)
+	rhs.Mul(rhs, u).Add(rhs, curve.fieldOne).Mul(rhs, u)
+
+	return lhs.Equals(rhs)
+}
+
+// ToMontgomery returns the coordinates of p on the Montgomery form of the
+// curve. The point (0, -1) of order 2 maps to (0, 0). The identity maps to the
+// point at infinity, which has no affine coordinates, so ToMontgomery returns
+// ErrMontgomeryInfinity for it.
+func (p *Point) ToMontgomery() (u, v *FieldElement, err error) {
+	curve := p.curve
+	if p.IsIdentity() {
+		return nil, nil, ErrMontgomeryInfinity
+	}
+	if p.x.Equals(curve.fieldZero) {
+		return curve.newFieldElement(nil), curve.newFieldElement(nil), nil
+	}
+
+	// u = (1 + y) / (1 - y)
+	u = curve.newFieldElement(nil).Sub(curve.fieldOne, p.y)
+	u.ModInverse(u)
+	u.Mul(u, curve.newFieldElement(nil).Add(curve.fieldOne, p.y))
+
+	// v = u / x
+	v = curve.newFieldElement(nil).ModInverse(p.x)
+	v.Mul(v, u)
+
+	return u, v, nil
+}
+
+// FromMontgomery returns the twisted Edwards point corresponding to (u, v) on
+// the Montgomery form of the curve. It returns ErrInvalidPoint if (u, v) is not
+// on the Montgomery curve or corresponds to a point at infinity of the Edwards
+// form, which can only happen on curves where d/a is a square.
+func (curve *Jubjub) FromMontgomery(u, v *FieldElement) (*Point, error) {
+	if !curve.isOnMontgomery(u, v) {
+		return nil, ErrInvalidPoint
+	}
+
+	if u.Equals(curve.fieldZero) {
+		// (0, 0) is the Montgomery point of order 2.
+		p := curve.Identity()
+		p.y.Neg(p.y)
+		return p, nil
+	}
+
+	uPlusOne := curve.newFieldElement(nil).Add(u, curve.fieldOne)
+	if v.Equals(curve.fieldZero) || uPlusOne.Equals(curve.fieldZero) {
+		return nil, ErrInvalidPoint
+	}
+
+	// x = u / v
+	x := curve.newFieldElement(nil).ModInverse(v)
+	x.Mul(x, u)
+
+	// y = (u - 1) / (u + 1)
+	y := curve.newFieldElement(nil).ModInverse(uPlusOne)
+	y.Mul(y, curve.newFieldElement(nil).Sub(u, curve.fieldOne))
+
+	p := &Point{curve, x, y}
+	if !p.IsOnCurve() {
+		return nil, ErrInvalidPoint
+	}
+	return p, nil
+}
+
+// ScalarMultU computes the u-coordinate of [scalar]P given the 32-byte
+// little-endian u-coordinate of P on the Montgomery form of the curve, in the
+// manner of X25519. Unlike X25519, which clamps the scalar to clear the
+// cofactor, ScalarMultU takes any scalar and instead requires P to be in the
+// prime-order subgroup. It returns ErrInvalidPoint if u is not canonical, is
+// the u-coordinate of a point on the quadratic twist, or is the u-coordinate
+// of a point with a small-order component. The checks cost a Legendre symbol
+// and a second ladder run over the subgroup order.
+//
+// The x-only Montgomery ladder from RFC 7748 always runs for the bit length of
+// the subgroup order and swaps its registers with a mask instead of branching
+// on the scalar. The field arithmetic underneath is big.Int, which isn't fixed
+// width, so the timing can still depend on the values; ScalarMultU is not
+// constant time. It returns ErrMontgomeryInfinity if the result is the point
+// at infinity, which would yield an all-zero shared secret.
+func (curve *Jubjub) ScalarMultU(scalar *Scalar, u []byte) ([]byte, error) {
+	x1, err := curve.FeFromCanonicalBytes(u)
+	if err != nil {
+		return nil, ErrInvalidPoint
+	}
+	// The ladder can't tell (0, 0), of order 2, from the point at infinity.
+	if x1.Equals(curve.fieldZero) || !curve.isMontgomeryU(x1) {
+		return nil, ErrInvalidPoint
+	}
+	if _, z := curve.montgomeryLadder(curve.subgroupOrder, x1); !z.Equals(curve.fieldZero) {
+		return nil, ErrInvalidPoint
+	}
+
+	x2, z2 := curve.montgomeryLadder(scalar.n, x1)
+	if z2.Equals(curve.fieldZero) || x2.Equals(curve.fieldZero) {
+		return nil, ErrMontgomeryInfinity
+	}
+	z2.ModInverse(z2)
+	return x2.Mul(x2, z2).ToBytes(), nil
+}
+
+// isMontgomeryU reports whether u is the u-coordinate of a point on the
+// Montgomery curve rather than its quadratic twist, that is, whether
+// (u^3 + A*u^2 + u) / B is a square.
+func (curve *Jubjub) isMontgomeryU(u *FieldElement) bool {
+	A, B := curve.MontgomeryParams()
+
+	rhs := curve.newFieldElement(nil).Add(u, A)
+	rhs.Mul(rhs, u).Add(rhs, curve.fieldOne).Mul(rhs, u)
+	rhs.Mul(rhs, B.ModInverse(B))
+
+	return curve.newFieldElement(nil).ModSqrt(rhs) != nil
+}
+
+// montgomeryLadder returns the projective u-coordinate (x2 : z2) of [k]P for
+// the point P with u-coordinate x1, running for the bit length of the
+// subgroup order. k must be no longer than that.
+func (curve *Jubjub) montgomeryLadder(k *big.Int, x1 *FieldElement) (x2, z2 *FieldElement) {
+	// a24 = (A - 2) / 4
+	A, _ := curve.MontgomeryParams()
+	a24 := curve.newFieldElement(big.NewInt(4))
+	a24.ModInverse(a24).Mul(a24, curve.newFieldElement(nil).Sub(A, curve.newFieldElement(big.NewInt(2))))
+
+	x2, z2 = curve.newFieldElement(big.NewInt(1)), curve.newFieldElement(nil)
+	x3, z3 := curve.newFieldElement(nil).Set(x1), curve.newFieldElement(big.NewInt(1))
+
+	t0 := curve.newFieldElement(nil)
+	aa := curve.newFieldElement(nil)
+	bb := curve.newFieldElement(nil)
+	e := curve.newFieldElement(nil)
+	da := curve.newFieldElement(nil)
+	cb := curve.newFieldElement(nil)
+
+	var swap uint
+	for i := curve.subgroupOrder.BitLen() - 1; i >= 0; i-- {
+		bit := k.Bit(i)
+		x2.condSwap(x3, swap^bit)
+		z2.condSwap(z3, swap^bit)
+		swap = bit
+
+		t0.Add(x2, z2) // A = x2 + z2
+		aa.Mul(t0, t0) // AA = A^2
+		da.Sub(x3, z3) // D = x3 - z3
+		da.Mul(da, t0) // DA = D * A
+		t0.Sub(x2, z2) // B = x2 - z2
+		bb.Mul(t0, t0) // BB = B^2
+		cb.Add(x3, z3) // C = x3 + z3
+		cb.Mul(cb, t0) // CB = C * B
+		e.Sub(aa, bb)  // E = AA - BB
+		x3.Add(da, cb) // x3 = (DA + CB)^2
+		x3.Mul(x3, x3)
+		z3.Sub(da, cb) // z3 = x1 * (DA - CB)^2
+		z3.Mul(z3, z3)
+		z3.Mul(z3, x1)
+		x2.Mul(aa, bb) // x2 = AA * BB
+		z2.Mul(a24, e) // z2 = E * (AA + a24 * E)
+		z2.Add(z2, aa)
+		z2.Mul(z2, e)
+	}
+	x2.condSwap(x3, swap)
+	z2.condSwap(z3, swap)
+	return x2, z2
+}
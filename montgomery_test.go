@@ -0,0 +1,127 @@
+package jubjub
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestMontgomeryParams(t *testing.T) {
+	tests := []struct {
+		params *CurveParams
+		A, B   int64
+	}{
+		{JubjubParams(), 40962, -40964},
+		// EIP-2494 gives the Montgomery form of Baby Jubjub as v^2 = u^3 + 168698u^2 + u.
+		{BabyJubjubParams(), 168698, 1},
+	}
+
+	for _, tt := range tests {
+		curve, err := NewCurve(tt.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		A, B := curve.MontgomeryParams()
+		wantA := curve.newFieldElement(big.NewInt(tt.A))
+		wantB := curve.newFieldElement(new(big.Int).Mod(big.NewInt(tt.B), tt.params.P))
+		if !A.Equals(wantA) || !B.Equals(wantB) {
+			t.Errorf("%s: incorrect Montgomery parameters A = %v, B = %v", tt.params.Name, A.n, B.n)
+		}
+	}
+}
+
+func TestMontgomeryConversion(t *testing.T) {
+	for _, params := range []*CurveParams{JubjubParams(), BabyJubjubParams(), BandersnatchParams()} {
+		curve, _ := NewCurve(params)
+
+		p := curve.Generator()
+		for i := 0; i < 8; i++ {
+			u, v, err := p.ToMontgomery()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !curve.isOnMontgomery(u, v) {
+				t.Errorf("%s: image of point %d isn't on the Montgomery curve", params.Name, i)
+			}
+			q, err := curve.FromMontgomery(u, v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !q.Equals(p) {
+				t.Errorf("%s: point %d didn't roundtrip", params.Name, i)
+			}
+			p.Add(p, curve.Generator())
+		}
+
+		if _, _, err := curve.Identity().ToMontgomery(); err != ErrMontgomeryInfinity {
+			t.Errorf("%s: converted the identity", params.Name)
+		}
+
+		order2 := curve.Identity()
+		order2.y.Neg(order2.y)
+		u, v, err := order2.ToMontgomery()
+		if err != nil || !u.Equals(curve.fieldZero) || !v.Equals(curve.fieldZero) {
+			t.Errorf("%s: (0, -1) didn't map to (0, 0)", params.Name)
+		}
+		if q, err := curve.FromMontgomery(u, v); err != nil || !q.Equals(order2) {
+			t.Errorf("%s: (0, 0) didn't map to (0, -1)", params.Name)
+		}
+
+		if _, err := curve.FromMontgomery(curve.fieldOne, curve.fieldOne); err != ErrInvalidPoint {
+			t.Errorf("%s: accepted a point off the Montgomery curve", params.Name)
+		}
+	}
+}
+
+func TestScalarMultU(t *testing.T) {
+	curve := Curve()
+	g := curve.SubgroupGenerator()
+	gU, _, _ := g.ToMontgomery()
+
+	a, _ := curve.ScalarFromBytes(bytes.Repeat([]byte{0x5a}, 32))
+	b, _ := curve.ScalarFromBytes(bytes.Repeat([]byte{0xc3}, 32))
+
+	aG, _ := curve.ScalarMult(a, g)
+	wantU, _, _ := aG.ToMontgomery()
+
+	aU, err := curve.ScalarMultU(a, gU.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(aU, wantU.ToBytes()) {
+		t.Errorf("Incorrect ladder output:\nWant: %x\nHave: %x", wantU.ToBytes(), aU)
+	}
+
+	bU, _ := curve.ScalarMultU(b, gU.ToBytes())
+	abU, _ := curve.ScalarMultU(b, aU)
+	baU, _ := curve.ScalarMultU(a, bU)
+	if !bytes.Equal(abU, baU) {
+		t.Error("Diffie-Hellman outputs differ")
+	}
+
+	zero, _ := curve.ScalarFromBig(big.NewInt(0))
+	if _, err := curve.ScalarMultU(zero, gU.ToBytes()); err != ErrMontgomeryInfinity {
+		t.Error("Expected the point at infinity for [0]G")
+	}
+	if _, err := curve.ScalarMultU(a, make([]byte, 32)); err != ErrInvalidPoint {
+		t.Error("Accepted the point of order 2")
+	}
+	if _, err := curve.ScalarMultU(a, bytes.Repeat([]byte{0xff}, 32)); err != ErrInvalidPoint {
+		t.Error("Accepted a non-canonical u-coordinate")
+	}
+
+	// The full group generator has a small-order component.
+	fullU, _, _ := curve.Generator().ToMontgomery()
+	if _, err := curve.ScalarMultU(a, fullU.ToBytes()); err != ErrInvalidPoint {
+		t.Error("Accepted a point outside the prime-order subgroup")
+	}
+
+	// Find a u-coordinate on the quadratic twist.
+	twist := curve.newFieldElement(big.NewInt(2))
+	for curve.isMontgomeryU(twist) {
+		twist.Add(twist, curve.fieldOne)
+	}
+	if _, err := curve.ScalarMultU(a, twist.ToBytes()); err != ErrInvalidPoint {
+		t.Error("Accepted a point on the twist")
+	}
+}
@@ -0,0 +1,124 @@
+package jubjub
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidRseed = errors.New("rseed is not a valid rcm")
+)
+
+// Sapling note plaintext lead bytes. Notes with lead byte 0x02 derive rcm and esk
+// from rseed as specified in ZIP 212.
+const (
+	NoteLeadByteV1 byte = 0x01
+	NoteLeadByteV2 byte = 0x02
+)
+
+// Note is a Sapling note: a value sent to a payment address.
+type Note struct {
+	Recipient *PaymentAddress
+	Value     uint64
+
+	// LeadByte determines how Rseed is interpreted. For NoteLeadByteV1 it is
+	// rcm itself, and for NoteLeadByteV2 it is a seed from which rcm and esk are
+	// derived.
+	LeadByte byte
+	Rseed    [32]byte
+}
+
+// prfExpand computes PRF^expand_sk(t) = BLAKE2b-512("Zcash_ExpandSeed", sk || t).
+func prfExpand(sk []byte, t ...[]byte) []byte {
+	h := newBlake2b(64, "Zcash_ExpandSeed")
+	h.Write(sk)
+	for _, b := range t {
+		h.Write(b)
+	}
+	return h.Sum(nil)
+}
+
+// Rcm returns the note commitment randomness.
+func (n *Note) Rcm() (*Scalar, error) {
+	curve := n.Recipient.PkD.curve
+
+	switch n.LeadByte {
+	case NoteLeadByteV1:
+		rcm, err := curve.ScalarFromBytes(n.Rseed[:])
+		if err != nil {
+			return nil, ErrInvalidRseed
+		}
+		return rcm, nil
+	case NoteLeadByteV2:
+		return curve.ScalarFromUniformBytes(prfExpand(n.Rseed[:], []byte{0x04})), nil
+	default:
+		return nil, ErrInvalidLeadByte
+	}
+}
+
+// deriveEsk returns the ephemeral secret key derived from rseed for
+// NoteLeadByteV2 notes, and nil for earlier notes where esk is random.
+func (n *Note) deriveEsk() *Scalar {
+	if n.LeadByte != NoteLeadByteV2 {
+		return nil
+	}
+	return n.Recipient.PkD.curve.ScalarFromUniformBytes(prfExpand(n.Rseed[:], []byte{0x05}))
+}
+
+// Commitment computes the note commitment
+// cm = NoteCommit_rcm(repr(g_d), repr(pk_d), v) and returns the full point.
+// The value that appears on chain is cm.ExtractJ().
+func (n *Note) Commitment() (*Point, error) {
+	curve := n.Recipient.PkD.curve
+
+	gd, err := curve.DiversifyHash(n.Recipient.Diversifier)
+	if err != nil {
+		return nil, err
+	}
+	rcm, err := n.Rcm()
+	if err != nil {
+		return nil, err
+	}
+
+	var value [8]byte
+	binary.LittleEndian.PutUint64(value[:], n.Value)
+
+	msg := []bool{true, true, true, true, true, true}
+	msg = append(msg, bytesToBits(value[:])...)
+	msg = append(msg, bytesToBits(gd.Compress())...)
+	msg = append(msg, bytesToBits(n.Recipient.PkD.Compress())...)
+
+	// WindowedPedersenCommit_r(s) = PedersenHashToPoint("Zcash_PH", s) + [r] FindGroupHash("Zcash_PH", "r")
+	cm := curve.PedersenHashToPoint("Zcash_PH", msg)
+	blind, err := curve.ScalarMult(rcm, curve.generator("Zcash_PH", []byte("r")))
+	if err != nil {
+		return nil, err
+	}
+
+	return cm.Add(cm, blind), nil
+}
+
+// Nullifier computes the nullifier of the note with commitment cm at the given
+// position in the note commitment tree:
+//
+//	rho = cm + [position] J
+//	nf  = BLAKE2s-256("Zcash_nf", repr(nk) || repr(rho))
+//
+// where J = FindGroupHash("Zcash_J_", "") is the nullifier position generator.
+func Nullifier(nk *Point, cm *Point, position uint64) [32]byte {
+	curve := cm.curve
+
+	pos, _ := curve.ScalarFromBig(new(big.Int).SetUint64(position))
+	rho, _ := curve.ScalarMult(pos, curve.generator("Zcash_J_", nil))
+	rho.Add(rho, cm)
+
+	h := newBlake2s("Zcash_nf")
+	h.Write(nk.Compress())
+	h.Write(rho.Compress())
+
+	var nf [32]byte
+	copy(nf[:], h.Sum(nil))
+	return nf
+}
@@ -0,0 +1,262 @@
+package jubjub
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	// MemoSize is the length in bytes of a Sapling memo field.
+	MemoSize = 512
+
+	// CompactNotePlaintextSize is the length of a note plaintext without its memo.
+	CompactNotePlaintextSize = 1 + DiversifierLength + 8 + 32
+
+	// NotePlaintextSize is the length of a full note plaintext.
+	NotePlaintextSize = CompactNotePlaintextSize + MemoSize
+
+	// EncCiphertextSize is the length of the encCiphertext field of an output.
+	EncCiphertextSize = NotePlaintextSize + chacha20poly1305.Overhead
+
+	// OutPlaintextSize is the length of the outgoing plaintext repr(pk_d) || esk.
+	OutPlaintextSize = 32 + 32
+
+	// OutCiphertextSize is the length of the outCiphertext field of an output.
+	OutCiphertextSize = OutPlaintextSize + chacha20poly1305.Overhead
+)
+
+var (
+	ErrInvalidLeadByte      = errors.New("unsupported note plaintext lead byte")
+	ErrNoteDecryptionFailed = errors.New("note ciphertext could not be decrypted")
+	ErrInvalidNotePlaintext = errors.New("decrypted note plaintext is invalid")
+)
+
+// Memo is the memo field of a Sapling note.
+type Memo [MemoSize]byte
+
+// OutgoingViewingKey is a Sapling outgoing viewing key, which lets a sender
+// recover the notes they created.
+type OutgoingViewingKey [32]byte
+
+// notePlaintext is the decoded form of np = leadByte || d || v || rseed || memo.
+type notePlaintext struct {
+	leadByte byte
+	d        Diversifier
+	value    uint64
+	rseed    [32]byte
+	memo     Memo
+}
+
+// parseNotePlaintext decodes a full or compact note plaintext. A compact plaintext leaves the memo empty.
+func parseNotePlaintext(in []byte) (*notePlaintext, error) {
+	if len(in) != NotePlaintextSize && len(in) != CompactNotePlaintextSize {
+		return nil, ErrInvalidNotePlaintext
+	}
+
+	np := &notePlaintext{leadByte: in[0]}
+	if np.leadByte != NoteLeadByteV1 && np.leadByte != NoteLeadByteV2 {
+		return nil, ErrInvalidLeadByte
+	}
+
+	in = in[1:]
+	copy(np.d[:], in[:DiversifierLength])
+	in = in[DiversifierLength:]
+	np.value = binary.LittleEndian.Uint64(in[:8])
+	in = in[8:]
+	copy(np.rseed[:], in[:32])
+	in = in[32:]
+	copy(np.memo[:], in)
+
+	return np, nil
+}
+
+// marshal encodes the full note plaintext.
+func (np *notePlaintext) marshal() []byte {
+	out := make([]byte, 0, NotePlaintextSize)
+	out = append(out, np.leadByte)
+	out = append(out, np.d[:]...)
+
+	var value [8]byte
+	binary.LittleEndian.PutUint64(value[:], np.value)
+	out = append(out, value[:]...)
+
+	out = append(out, np.rseed[:]...)
+	out = append(out, np.memo[:]...)
+	return out
+}
+
+// note returns the note described by the plaintext, sent to addr.
+func (np *notePlaintext) note(addr *PaymentAddress) *Note {
+	return &Note{
+		Recipient: addr,
+		Value:     np.value,
+		LeadByte:  np.leadByte,
+		Rseed:     np.rseed,
+	}
+}
+
+// kdfSapling computes KDF^Sapling(sharedSecret, epk) = BLAKE2b-256("Zcash_SaplingKDF", repr(sharedSecret) || repr(epk)).
+func kdfSapling(sharedSecret, epk *Point) []byte {
+	h := newBlake2b(32, "Zcash_SaplingKDF")
+	h.Write(sharedSecret.Compress())
+	h.Write(epk.Compress())
+	return h.Sum(nil)
+}
+
+// prfOck computes the outgoing cipher key
+// ock = BLAKE2b-256("Zcash_Derive_ock", ovk || repr(cv) || cmu || repr(epk)).
+func prfOck(ovk OutgoingViewingKey, cv *Point, cmu []byte, epk *Point) []byte {
+	h := newBlake2b(32, "Zcash_Derive_ock")
+	h.Write(ovk[:])
+	h.Write(cv.Compress())
+	h.Write(cmu)
+	h.Write(epk.Compress())
+	return h.Sum(nil)
+}
+
+// symEncrypt produces a Sym ciphertext under a single-use key.
+func symEncrypt(key, plaintext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [chacha20poly1305.NonceSize]byte
+	return aead.Seal(nil, nonce[:], plaintext, nil), nil
+}
+
+// symDecrypt opens a Sym ciphertext. Sapling uses ChaCha20-Poly1305 with an
+// all-zero nonce, which is safe because every key encrypts exactly one message.
+func symDecrypt(key, ciphertext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [chacha20poly1305.NonceSize]byte
+	return aead.Open(nil, nonce[:], ciphertext, nil)
+}
+
+// TryDecryptNote attempts to decrypt the encCiphertext of a Sapling output with
+// an incoming viewing key. On success it returns the note, whose commitment has
+// been checked against cmu, and its memo.
+func TryDecryptNote(ivk *IncomingViewingKey, epk *Point, cmu []byte, encCiphertext []byte) (*Note, *Memo, error) {
+	if len(encCiphertext) != EncCiphertextSize {
+		return nil, nil, ErrNoteDecryptionFailed
+	}
+
+	sharedSecret, err := ivk.curve.KAAgree(ivk.ivk, epk)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plaintext, err := symDecrypt(kdfSapling(sharedSecret, epk), encCiphertext)
+	if err != nil {
+		return nil, nil, ErrNoteDecryptionFailed
+	}
+
+	np, err := parseNotePlaintext(plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr, err := ivk.Address(np.d)
+	if err != nil {
+		return nil, nil, ErrInvalidNotePlaintext
+	}
+
+	note := np.note(addr)
+	if err := note.checkOutput(epk, cmu); err != nil {
+		return nil, nil, err
+	}
+
+	return note, &np.memo, nil
+}
+
+// TryRecoverOutputNote attempts to recover a note sent by the holder of ovk. It
+// decrypts outCiphertext to learn the recipient's pk_d and the ephemeral secret
+// esk, then uses them to decrypt encCiphertext. On success it returns the note,
+// whose commitment has been checked against cmu, and its memo.
+func TryRecoverOutputNote(ovk OutgoingViewingKey, cv *Point, cmu []byte, epk *Point, encCiphertext, outCiphertext []byte) (*Note, *Memo, error) {
+	if len(encCiphertext) != EncCiphertextSize || len(outCiphertext) != OutCiphertextSize {
+		return nil, nil, ErrNoteDecryptionFailed
+	}
+
+	curve := epk.curve
+
+	op, err := symDecrypt(prfOck(ovk, cv, cmu, epk), outCiphertext)
+	if err != nil {
+		return nil, nil, ErrNoteDecryptionFailed
+	}
+
+	pkd, err := curve.Decompress(op[:32])
+	if err != nil {
+		return nil, nil, ErrInvalidNotePlaintext
+	}
+	esk, err := curve.ScalarFromBytes(op[32:])
+	if err != nil {
+		return nil, nil, ErrInvalidNotePlaintext
+	}
+
+	sharedSecret, err := curve.KAAgree(esk, pkd)
+	if err != nil {
+		return nil, nil, ErrInvalidNotePlaintext
+	}
+
+	plaintext, err := symDecrypt(kdfSapling(sharedSecret, epk), encCiphertext)
+	if err != nil {
+		return nil, nil, ErrNoteDecryptionFailed
+	}
+
+	np, err := parseNotePlaintext(plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The recovered esk must actually be the one behind epk for this diversifier.
+	gd, err := curve.DiversifyHash(np.d)
+	if err != nil {
+		return nil, nil, ErrInvalidNotePlaintext
+	}
+	expected, err := curve.KADerivePublic(esk, gd)
+	if err != nil || !expected.Equals(epk) {
+		return nil, nil, ErrInvalidNotePlaintext
+	}
+
+	note := np.note(&PaymentAddress{np.d, pkd})
+	if err := note.checkOutput(epk, cmu); err != nil {
+		return nil, nil, err
+	}
+
+	return note, &np.memo, nil
+}
+
+// checkOutput verifies that a decrypted note matches the public fields of its
+// output: the note commitment must match cmu, and under ZIP 212 epk must be
+// derived from the note's rseed.
+func (n *Note) checkOutput(epk *Point, cmu []byte) error {
+	if esk := n.deriveEsk(); esk != nil {
+		curve := n.Recipient.PkD.curve
+		gd, err := curve.DiversifyHash(n.Recipient.Diversifier)
+		if err != nil {
+			return ErrInvalidNotePlaintext
+		}
+		expected, err := curve.KADerivePublic(esk, gd)
+		if err != nil || !expected.Equals(epk) {
+			return ErrInvalidNotePlaintext
+		}
+	}
+
+	cm, err := n.Commitment()
+	if err != nil {
+		return ErrInvalidNotePlaintext
+	}
+	if !bytes.Equal(cm.ExtractJ(), cmu) {
+		return ErrInvalidNotePlaintext
+	}
+
+	return nil
+}
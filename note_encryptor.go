@@ -0,0 +1,113 @@
+package jubjub
+
+import (
+	"crypto/rand"
+	"io"
+)
+
+// NoteEncryptor produces the encCiphertext and outCiphertext of a single
+// Sapling output, along with the ephemeral key pair they are bound to.
+type NoteEncryptor struct {
+	curve *Jubjub
+	ovk   *OutgoingViewingKey
+	note  *Note
+	memo  Memo
+	esk   *Scalar
+	epk   *Point
+	rand  io.Reader
+}
+
+// NewNoteEncryptor prepares to encrypt note and memo to the note's recipient.
+// A nil memo encrypts the "no memo" value, 0xF6 followed by zeros.
+// For NoteLeadByteV2 notes esk is derived from rseed as ZIP 212 requires;
+// otherwise it is sampled from random. If ovk is nil, the outgoing ciphertext
+// will be random and the sender won't be able to recover the note. If random
+// is nil, crypto/rand is used.
+func NewNoteEncryptor(ovk *OutgoingViewingKey, note *Note, memo *Memo, random io.Reader) (*NoteEncryptor, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+
+	curve := note.Recipient.PkD.curve
+	ne := &NoteEncryptor{
+		curve: curve,
+		ovk:   ovk,
+		note:  note,
+		rand:  random,
+	}
+	if memo != nil {
+		ne.memo = *memo
+	} else {
+		ne.memo[0] = 0xF6
+	}
+
+	ne.esk = note.deriveEsk()
+	if ne.esk == nil {
+		var err error
+		if ne.esk, err = curve.RandomScalar(random); err != nil {
+			return nil, err
+		}
+	}
+
+	gd, err := curve.DiversifyHash(note.Recipient.Diversifier)
+	if err != nil {
+		return nil, err
+	}
+	ne.epk, err = curve.KADerivePublic(ne.esk, gd)
+	if err != nil {
+		return nil, err
+	}
+
+	return ne, nil
+}
+
+// Esk returns the ephemeral secret key.
+func (ne *NoteEncryptor) Esk() *Scalar {
+	sc, _ := newScalar(nil, ne.curve.subgroupOrder)
+	sc.n.Set(ne.esk.n)
+	return sc
+}
+
+// Epk returns the ephemeral public key epk = [esk] g_d, which is published in the output.
+func (ne *NoteEncryptor) Epk() *Point {
+	return ne.epk.Clone()
+}
+
+// EncryptNotePlaintext returns the encCiphertext, which the recipient can open with their ivk.
+func (ne *NoteEncryptor) EncryptNotePlaintext() ([]byte, error) {
+	sharedSecret, err := ne.curve.KAAgree(ne.esk, ne.note.Recipient.PkD)
+	if err != nil {
+		return nil, err
+	}
+
+	np := &notePlaintext{
+		leadByte: ne.note.LeadByte,
+		d:        ne.note.Recipient.Diversifier,
+		value:    ne.note.Value,
+		rseed:    ne.note.Rseed,
+		memo:     ne.memo,
+	}
+	return symEncrypt(kdfSapling(sharedSecret, ne.epk), np.marshal())
+}
+
+// EncryptOutgoingPlaintext returns the outCiphertext, which lets the holder of
+// ovk recover the note. cv and cmu are the value commitment and note commitment
+// published in the same output.
+func (ne *NoteEncryptor) EncryptOutgoingPlaintext(cv *Point, cmu []byte) ([]byte, error) {
+	var ock, op []byte
+	if ne.ovk == nil {
+		ock = make([]byte, 32)
+		op = make([]byte, OutPlaintextSize)
+		if _, err := io.ReadFull(ne.rand, ock); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(ne.rand, op); err != nil {
+			return nil, err
+		}
+	} else {
+		ock = prfOck(*ne.ovk, cv, cmu, ne.epk)
+		op = append(ne.note.Recipient.PkD.Compress(), ne.esk.ToBytes()...)
+	}
+
+	return symEncrypt(ock, op)
+}
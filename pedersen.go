@@ -0,0 +1,76 @@
+package jubjub
+
+import (
+	"encoding/binary"
+	"math/big"
+)
+
+// pedersenChunksPerSegment is c, the number of 3-bit chunks hashed with each generator.
+const pedersenChunksPerSegment = 63
+
+// PedersenHashToPoint computes the Sapling Pedersen hash of the bit sequence msg
+// under the 8-byte personalization D, returning the resulting point.
+func (curve *Jubjub) PedersenHashToPoint(personalization string, msg []bool) *Point {
+	// Pad to a multiple of 3 bits.
+	padded := make([]bool, len(msg)+(3-len(msg)%3)%3)
+	copy(padded, msg)
+
+	result := curve.Identity()
+	segmentBits := 3 * pedersenChunksPerSegment
+	for i := 0; i*segmentBits < len(padded); i++ {
+		end := (i + 1) * segmentBits
+		if end > len(padded) {
+			end = len(padded)
+		}
+
+		var index [4]byte
+		binary.LittleEndian.PutUint32(index[:], uint32(i))
+		g := curve.generator(personalization, index[:])
+
+		segment, _ := curve.ScalarFromBig(encodePedersenSegment(padded[i*segmentBits : end]))
+		p, _ := curve.ScalarMult(segment, g)
+		result.Add(result, p)
+	}
+
+	return result
+}
+
+// encodePedersenSegment computes <M_i>, the sum of enc(m_j) * 2^(4*(j-1)) over the
+// 3-bit chunks of a segment, where enc(s0, s1, s2) = (1 - 2*s2) * (1 + s0 + 2*s1).
+func encodePedersenSegment(segment []bool) *big.Int {
+	sum := new(big.Int)
+	for j := 0; j < len(segment)/3; j++ {
+		enc := int64(1)
+		if segment[3*j] {
+			enc++
+		}
+		if segment[3*j+1] {
+			enc += 2
+		}
+		if segment[3*j+2] {
+			enc = -enc
+		}
+
+		term := big.NewInt(enc)
+		term.Lsh(term, uint(4*j))
+		sum.Add(sum, term)
+	}
+	return sum
+}
+
+// ExtractJ returns the little-endian encoding of the point's u-coordinate, which
+// is how Sapling represents note commitments and Merkle tree nodes.
+func (p *Point) ExtractJ() []byte {
+	return p.x.ToBytes()
+}
+
+// bytesToBits returns the bits of in, least significant bit of each byte first.
+func bytesToBits(in []byte) []bool {
+	out := make([]bool, 0, 8*len(in))
+	for _, b := range in {
+		for i := uint(0); i < 8; i++ {
+			out = append(out, (b>>i)&1 == 1)
+		}
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package jubjub
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+)
+
+// wideReductionSize is the number of random bytes reduced to produce one
+// scalar or field element. For the curves supported here, 512 bits leave a
+// bias of less than 2^-256.
+const wideReductionSize = 64
+
+// readWide reads wideReductionSize bytes from r, or from crypto/rand if r is
+// nil, and returns them as a little-endian integer reduced modulo m.
+func readWide(r io.Reader, m *big.Int) (*big.Int, error) {
+	if r == nil {
+		r = rand.Reader
+	}
+
+	var buf [wideReductionSize]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+
+	n := new(big.Int).SetBytes(buf[:])
+	return n.Mod(n, m), nil
+}
+
+// RandomScalar returns a uniformly random nonzero scalar read from r, which
+// defaults to crypto/rand if nil.
+func (curve *Jubjub) RandomScalar(r io.Reader) (*Scalar, error) {
+	for {
+		n, err := readWide(r, curve.subgroupOrder)
+		if err != nil {
+			return nil, err
+		}
+		if n.Sign() != 0 {
+			return curve.ScalarFromBig(n)
+		}
+	}
+}
+
+// RandomFieldElement returns a uniformly random element of the base field read
+// from r, which defaults to crypto/rand if nil.
+func (curve *Jubjub) RandomFieldElement(r io.Reader) (*FieldElement, error) {
+	n, err := readWide(r, curve.fieldOrder)
+	if err != nil {
+		return nil, err
+	}
+	return curve.newFieldElement(n), nil
+}
+
+// RandomSubgroupPoint returns a uniformly random point of the prime-order
+// subgroup other than the identity, read from r, which defaults to crypto/rand
+// if nil.
+func (curve *Jubjub) RandomSubgroupPoint(r io.Reader) (*Point, error) {
+	k, err := curve.RandomScalar(r)
+	if err != nil {
+		return nil, err
+	}
+	return curve.ScalarMult(k, curve.SubgroupGenerator())
+}
@@ -0,0 +1,74 @@
+package jubjub
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"testing"
+)
+
+func TestRandomScalar(t *testing.T) {
+	curve := Curve()
+
+	// A reader of all 0xff bytes gives (2^512 - 1) mod r.
+	want := new(big.Int).Lsh(big.NewInt(1), 512)
+	want.Sub(want, big.NewInt(1)).Mod(want, curve.subgroupOrder)
+	sc, err := curve.RandomScalar(bytes.NewReader(bytes.Repeat([]byte{0xff}, 64)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.n.Cmp(want) != 0 {
+		t.Errorf("Incorrect wide reduction:\nWant: %x\nHave: %x", want, sc.n)
+	}
+
+	// Zero is rejected and the next 64 bytes are used instead.
+	in := append(make([]byte, 64), 1)
+	in = append(in, make([]byte, 63)...)
+	sc, err = curve.RandomScalar(bytes.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.n.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Zero wasn't rejected: %x", sc.n)
+	}
+
+	if _, err := curve.RandomScalar(bytes.NewReader(make([]byte, 63))); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected a short read error, have %v", err)
+	}
+
+	a, _ := curve.RandomScalar(nil)
+	b, _ := curve.RandomScalar(nil)
+	if a.Equals(b) {
+		t.Error("crypto/rand returned the same scalar twice")
+	}
+}
+
+func TestRandomFieldElement(t *testing.T) {
+	curve := Curve()
+
+	fe, err := curve.RandomFieldElement(bytes.NewReader(make([]byte, 64)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fe.Equals(curve.fieldZero) {
+		t.Error("Zero field element wasn't allowed")
+	}
+
+	a, _ := curve.RandomFieldElement(nil)
+	b, _ := curve.RandomFieldElement(nil)
+	if a.Equals(b) || a.n.Cmp(curve.fieldOrder) >= 0 {
+		t.Error("Bad random field elements")
+	}
+}
+
+func TestRandomSubgroupPoint(t *testing.T) {
+	curve := Curve()
+
+	p, err := curve.RandomSubgroupPoint(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.IsIdentity() || !p.inPrimeSubgroup() {
+		t.Error("Random point isn't a nonzero point of the prime-order subgroup")
+	}
+}
@@ -0,0 +1,153 @@
+package jubjub
+
+import (
+	"crypto/rand"
+	"io"
+)
+
+// RedJubjubSignatureSize is the length of an encoded signature, R || S.
+const RedJubjubSignatureSize = 64
+
+// redJubjubRandomSize is the length of the random input Z mixed into hedged nonces.
+const redJubjubRandomSize = 32
+
+// RedJubjub is the RedDSA signature scheme from section 5.4.7 of the Zcash
+// protocol specification, instantiated on the curve with a fixed base point.
+type RedJubjub struct {
+	curve *Jubjub
+	base  *Point
+}
+
+// NewRedJubjub returns RedJubjub with the given base point, which should
+// generate the prime-order subgroup.
+func (curve *Jubjub) NewRedJubjub(base *Point) *RedJubjub {
+	return &RedJubjub{curve, base.Clone()}
+}
+
+// SpendAuthSig returns RedJubjub with base G, as used for spend authorization
+// signatures under ak and its randomizations rk.
+func (curve *Jubjub) SpendAuthSig() *RedJubjub {
+	return curve.NewRedJubjub(curve.SpendingKeyGenerator())
+}
+
+// BindingSig returns RedJubjub with the value commitment randomness base, as
+// used for Sapling binding signatures.
+func (curve *Jubjub) BindingSig() *RedJubjub {
+	return curve.NewRedJubjub(curve.ValueCommitmentRandomnessGenerator())
+}
+
+// redJubjubHash is H*(B) = LEOS2IP(BLAKE2b-512("Zcash_RedJubjubH", B)) mod r.
+func (curve *Jubjub) redJubjubHash(parts ...[]byte) *Scalar {
+	h := newBlake2b(64, "Zcash_RedJubjubH")
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return curve.ScalarFromUniformBytes(h.Sum(nil))
+}
+
+// DerivePublic returns the validating key vk = [sk] P_G.
+func (rj *RedJubjub) DerivePublic(sk *Scalar) (*Point, error) {
+	return rj.curve.ScalarMult(sk, rj.base)
+}
+
+// RandomizePrivate returns the randomized signing key sk + alpha.
+func (rj *RedJubjub) RandomizePrivate(sk, alpha *Scalar) *Scalar {
+	sc, _ := newScalar(nil, rj.curve.subgroupOrder)
+	return sc.Add(sk, alpha)
+}
+
+// RandomizePublic returns the randomized validating key vk + [alpha] P_G,
+// which validates signatures made with RandomizePrivate(sk, alpha).
+func (rj *RedJubjub) RandomizePublic(vk *Point, alpha *Scalar) (*Point, error) {
+	p, err := rj.curve.ScalarMult(alpha, rj.base)
+	if err != nil {
+		return nil, err
+	}
+	return p.Add(p, vk), nil
+}
+
+// Sign signs msg with sk. Rather than drawing the nonce input T purely from
+// random, which ruins the key if the randomness is ever repeated or biased,
+// it hedges by deriving T from sk and 32 bytes read from random, so that the
+// nonce H*(T || vk || M) stays secret and unique per message even if random
+// fails. If random is nil, crypto/rand is used.
+func (rj *RedJubjub) Sign(sk *Scalar, msg []byte, random io.Reader) ([]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+	z := make([]byte, redJubjubRandomSize)
+	if _, err := io.ReadFull(random, z); err != nil {
+		return nil, err
+	}
+	return rj.sign(sk, redJubjubNonceInput(sk, z), msg)
+}
+
+// SignDeterministic signs msg with sk using T derived from sk alone, in the
+// manner of RFC 6979 and Ed25519, so that signing the same message twice gives
+// the same signature. It needs no randomness at signing time.
+func (rj *RedJubjub) SignDeterministic(sk *Scalar, msg []byte) ([]byte, error) {
+	return rj.sign(sk, redJubjubNonceInput(sk, nil), msg)
+}
+
+// redJubjubNonceInput returns T = BLAKE2b-512("Jubjub_RedDSA_T_", LEBS2OSP(sk) || z).
+func redJubjubNonceInput(sk *Scalar, z []byte) []byte {
+	h := newBlake2b(64, "Jubjub_RedDSA_T_")
+	h.Write(sk.ToBytes())
+	h.Write(z)
+	return h.Sum(nil)
+}
+
+// sign is RedDSA.Sign with the nonce input T:
+//
+//	r = H*(T || vk || M), R = [r] P_G, S = r + H*(R || vk || M) * sk
+func (rj *RedJubjub) sign(sk *Scalar, T, msg []byte) ([]byte, error) {
+	vk, err := rj.DerivePublic(sk)
+	if err != nil {
+		return nil, err
+	}
+	vkBar := vk.Compress()
+
+	r := rj.curve.redJubjubHash(T, vkBar, msg)
+	R, err := rj.curve.ScalarMult(r, rj.base)
+	if err != nil {
+		return nil, err
+	}
+	rBar := R.Compress()
+
+	c := rj.curve.redJubjubHash(rBar, vkBar, msg)
+	S := c.Mul(c, sk).Add(c, r)
+
+	return append(rBar, S.ToBytes()...), nil
+}
+
+// Verify reports whether sig is a valid signature of msg under vk. R must be
+// a canonical point encoding and S must be less than the subgroup order, and
+// the check [h](-[S] P_G + R + [c] vk) = O multiplies by the cofactor as the
+// specification requires.
+func (rj *RedJubjub) Verify(vk *Point, msg, sig []byte) bool {
+	if len(sig) != RedJubjubSignatureSize {
+		return false
+	}
+
+	R, err := rj.curve.Decompress(sig[:32])
+	if err != nil {
+		return false
+	}
+	S, err := rj.curve.ScalarFromBytes(sig[32:])
+	if err != nil {
+		return false
+	}
+
+	c := rj.curve.redJubjubHash(sig[:32], vk.Compress(), msg)
+	cvk, err := rj.curve.ScalarMult(c, vk)
+	if err != nil {
+		return false
+	}
+	sp, err := rj.curve.ScalarMult(S, rj.base)
+	if err != nil {
+		return false
+	}
+
+	check := sp.Neg(sp).Add(sp, R).Add(sp, cvk)
+	return check.MulByCofactor().IsIdentity()
+}
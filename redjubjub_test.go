@@ -0,0 +1,157 @@
+package jubjub
+
+import (
+	"bytes"
+	"testing"
+)
+
+// From https://github.com/zcash/zcash-test-vectors/blob/master/zcash_test_vectors/sapling/redjubjub.py
+var redJubjubSignatures = []struct {
+	sk, vk, alpha, rsk, rvk string
+	m, sig, rsig            string
+}{
+	{
+		sk:    "18e28dea5c11817aeeb21a19981d28368ec438afc25a8db94ebe08d7a0288e09",
+		vk:    "9b0153b03d320fe23e2834d5d61dbb1f519b3f41f8f946152bf0c3f247d11807",
+		alpha: "ffd1a1273252b187f4ed326dfc98853e2917c2b36379b175da63b9ef6dda6c08",
+		rsk:   "6087383b30559b31609085b9009645ceb6a0c6612599d72880728e61244e7d03",
+		rvk:   "c1babcb6eae2b994ee6d65c10b9dad5940dc735b07504daed1e46b0709b45136",
+		m:     "0000000000000000000000000000000000000000000000000000000000000000",
+		sig:   "dca3bb2cb8f048ccab10aed77546c1dbb10cc4fb15ab02acaef944ddab8b6722545fda4c62046d69d98f922f4e8c210bc47b4fdde0a1947179804c1ace569005",
+		rsig:  "70c284504e90f0008e8ed2208f4969727a415ec3102c299e398b6c16572bd9643ee1011766681e406ee6bee3d03ee8f27176e32fbabdded20b0d1786a4ee1801",
+	},
+	{
+		sk:    "059654f961273dafda3b2677b35c18af6b11adfb9ee90b48935e557c8d5d9c04",
+		vk:    "faf6c3b737e8e611aafea52f03bb2786e18353ebe0d3139e3c54498780c8c199",
+		alpha: "c30b96208da800e10af02542ce694b7ed76a28299f85998e5d610812681bf003",
+		rsk:   "c8a1ea19efcf3d90e52b4cb981c6632d437cd5243e6fa5d6f0bf5d8ef5788c08",
+		rvk:   "d524dce7734069758a91f007a869505dfc4aba1720594d4d74f007700e62ee00",
+		m:     "0101010101010101010101010101010101010101010101010101010101010101",
+		sig:   "b5a1f32d3d50fc738b5c3b4e9960729ce4316ba7721a12686604feba6bd748450070cb922406fdfc5d60dea9be3a526a16cfeb877779fb782d5d41395b455f04",
+		rsig:  "5a5a20d200efddd498dfae2a9ef8cf01281a8919018a824cc7a4983b9a0d4a06ff172079e013d42a2a3a88a6520c86fce3b98e1efaa325832a6a5658d8dd7c0a",
+	},
+	{
+		sk:    "ade7abb551c79d0f0e42ef7f1206b87712a84a61dea3f37b42496d7efd12520c",
+		vk:    "369ea751762f839d25701a5eeb551ec4f06c1290b3b9c3a724402dec02739221",
+		alpha: "81922529a63ee743fc4fbbac45c4988316bc9b6e428b01a8d31fc1c2a6ca6205",
+		rsk:   "774dda0799f7ed828781e25fc4a9e8542829b2ce1ff48d1d6db9fadbb9283703",
+		rvk:   "0d92ad6d46edacd023d4d2ef703a6ca0a792cfc4b7da11c2353bc845a27a974d",
+		m:     "0202020202020202020202020202020202020202020202020202020202020202",
+		sig:   "1f3e8a94310c2071a70f9df5e79aa9e8485deccb178bdff9805fcbe6f7d551eee3c3542ca75c9d8d4adc54d72c3dbe28626d20785bb7f588c1a582b893dbb601",
+		rsig:  "d136214c5d528ea3d4cb7b631a6bb036064973a108b733a5e3a452ab52a659e567cb55d2644e74b6e8426f2a7dd2a04d2dda4935cc3820b77a9c1ab619863c05",
+	},
+}
+
+func TestRedJubjubVectors(t *testing.T) {
+	curve := Curve()
+	rj := curve.SpendAuthSig()
+
+	for i, tv := range redJubjubSignatures {
+		sk, err := curve.ScalarFromBytes(decodeHex(t, tv.sk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		alpha, err := curve.ScalarFromBytes(decodeHex(t, tv.alpha))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := decodeHex(t, tv.m)
+
+		vk, err := rj.DerivePublic(sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(vk.Compress(), decodeHex(t, tv.vk)) {
+			t.Errorf("Incorrect vk for test %d", i)
+		}
+
+		rsk := rj.RandomizePrivate(sk, alpha)
+		if !bytes.Equal(rsk.ToBytes(), decodeHex(t, tv.rsk)) {
+			t.Errorf("Incorrect rsk for test %d", i)
+		}
+		rvk, err := rj.RandomizePublic(vk, alpha)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(rvk.Compress(), decodeHex(t, tv.rvk)) {
+			t.Errorf("Incorrect rvk for test %d", i)
+		}
+
+		if !rj.Verify(vk, m, decodeHex(t, tv.sig)) {
+			t.Errorf("Signature %d didn't verify", i)
+		}
+		if !rj.Verify(rvk, m, decodeHex(t, tv.rsig)) {
+			t.Errorf("Randomized signature %d didn't verify", i)
+		}
+		if rj.Verify(rvk, m, decodeHex(t, tv.sig)) || rj.Verify(vk, m, decodeHex(t, tv.rsig)) {
+			t.Errorf("Signature %d verified under the wrong key", i)
+		}
+	}
+}
+
+func TestRedJubjubSign(t *testing.T) {
+	curve := Curve()
+	msg := []byte("spend authorization")
+
+	for _, rj := range []*RedJubjub{curve.SpendAuthSig(), curve.BindingSig()} {
+		sk, _ := curve.RandomScalar(nil)
+		vk, _ := rj.DerivePublic(sk)
+
+		sig, err := rj.Sign(sk, msg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sig) != RedJubjubSignatureSize || !rj.Verify(vk, msg, sig) {
+			t.Fatal("Hedged signature didn't verify")
+		}
+		if sig2, _ := rj.Sign(sk, msg, nil); bytes.Equal(sig, sig2) {
+			t.Error("Hedged signatures repeated")
+		}
+
+		det, err := rj.SignDeterministic(sk, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !rj.Verify(vk, msg, det) {
+			t.Fatal("Deterministic signature didn't verify")
+		}
+		if det2, _ := rj.SignDeterministic(sk, msg); !bytes.Equal(det, det2) {
+			t.Error("Deterministic signatures differ")
+		}
+		if det3, _ := rj.SignDeterministic(sk, []byte("another message")); bytes.Equal(det[:32], det3[:32]) {
+			t.Error("Deterministic nonce didn't depend on the message")
+		}
+
+		if rj.Verify(vk, []byte("spend authorisation"), sig) {
+			t.Error("Signature verified for the wrong message")
+		}
+		for _, i := range []int{0, 40} {
+			bad := append([]byte{}, sig...)
+			bad[i] ^= 1
+			if rj.Verify(vk, msg, bad) {
+				t.Errorf("Signature verified with byte %d modified", i)
+			}
+		}
+		if rj.Verify(vk, msg, sig[:63]) {
+			t.Error("Short signature verified")
+		}
+	}
+
+	// S must be less than the subgroup order.
+	rj := curve.SpendAuthSig()
+	sk, _ := curve.RandomScalar(nil)
+	vk, _ := rj.DerivePublic(sk)
+	sig, _ := rj.SignDeterministic(sk, msg)
+	S, _ := curve.ScalarFromBytes(sig[32:])
+	S.n.Add(S.n, curve.subgroupOrder)
+	be := S.n.Bytes()
+	for i := range sig[32:] {
+		sig[32+i] = 0
+		if i < len(be) {
+			sig[32+i] = be[len(be)-1-i]
+		}
+	}
+	if rj.Verify(vk, msg, sig) {
+		t.Error("Signature with non-canonical S verified")
+	}
+}
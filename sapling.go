@@ -0,0 +1,210 @@
+package jubjub
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidIvk                = errors.New("derived ivk was zero")
+	ErrInvalidDiversifier        = errors.New("not a valid diversifier")
+	ErrDiversifierSpaceExhausted = errors.New("no valid diversifier at or after the index")
+	ErrInvalidAddress            = errors.New("not a valid payment address")
+)
+
+// DeriveIvk computes the Sapling incoming viewing key ivk = CRH^ivk(repr(ak), repr(nk)),
+// which is BLAKE2s-256("Zcashivk", repr(ak) || repr(nk)) truncated to 251 bits.
+// It returns an error if the result is zero, which the protocol treats as an invalid key.
+func (curve *Jubjub) DeriveIvk(ak, nk *Point) (*Scalar, error) {
+	akRepr, err := ak.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	nkRepr, err := nk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	h := newBlake2s("Zcashivk")
+	h.Write(akRepr)
+	h.Write(nkRepr)
+	digest := h.Sum(nil)
+
+	// Truncate to 251 bits, which is always less than the subgroup order.
+	digest[31] &= 0x07
+
+	ivk, err := curve.ScalarFromBytes(digest)
+	if err != nil {
+		return nil, err
+	}
+	if ivk.n.Sign() == 0 {
+		return nil, ErrInvalidIvk
+	}
+
+	return ivk, nil
+}
+
+// ProofGenerationKey is the key (ak, nsk) that a spender gives to a prover so
+// that it can create spend proofs without being able to authorize spends.
+type ProofGenerationKey struct {
+	Ak  *Point
+	Nsk *Scalar
+}
+
+// ViewingKey is a Sapling viewing key (ak, nk).
+type ViewingKey struct {
+	Ak *Point
+	Nk *Point
+}
+
+// ViewingKey derives the viewing key (ak, nk) with nk = [nsk] H.
+func (pgk *ProofGenerationKey) ViewingKey() (*ViewingKey, error) {
+	curve := pgk.Ak.curve
+	nk, err := curve.ScalarMult(pgk.Nsk, curve.ProofGenerationKeyGenerator())
+	if err != nil {
+		return nil, err
+	}
+	return &ViewingKey{pgk.Ak.Clone(), nk}, nil
+}
+
+// IncomingViewingKey derives the incoming viewing key ivk = CRH^ivk(ak, nk).
+func (vk *ViewingKey) IncomingViewingKey() (*IncomingViewingKey, error) {
+	curve := vk.Ak.curve
+	ivk, err := curve.DeriveIvk(vk.Ak, vk.Nk)
+	if err != nil {
+		return nil, err
+	}
+	return curve.NewIncomingViewingKey(ivk)
+}
+
+// DiversifierLength is the length in bytes of a Sapling diversifier.
+const DiversifierLength = 11
+
+// Diversifier selects one of the many payment addresses belonging to an incoming viewing key.
+type Diversifier [DiversifierLength]byte
+
+// DiversifierIndex is an 88-bit little-endian index into the space of diversifiers.
+type DiversifierIndex [DiversifierLength]byte
+
+// increment adds one to the index in place. It returns false if the index overflowed.
+func (j *DiversifierIndex) increment() bool {
+	for i := range j {
+		j[i]++
+		if j[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// DiversifyHash computes the diversified base g_d = GroupHash("Zcash_gd", d).
+// It returns ErrInvalidDiversifier if d does not have a corresponding base.
+func (curve *Jubjub) DiversifyHash(d Diversifier) (*Point, error) {
+	gd, err := curve.GroupHash("Zcash_gd", d[:])
+	if err != nil {
+		return nil, ErrInvalidDiversifier
+	}
+	return gd, nil
+}
+
+// PaymentAddress is a Sapling payment address (d, pk_d).
+type PaymentAddress struct {
+	Diversifier Diversifier
+	PkD         *Point
+}
+
+// PaymentAddressSize is the length of an encoded payment address d || repr(pk_d).
+const PaymentAddressSize = DiversifierLength + 32
+
+// MarshalBinary encodes the address as d || repr(pk_d).
+func (addr *PaymentAddress) MarshalBinary() ([]byte, error) {
+	pkd, err := addr.PkD.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, PaymentAddressSize)
+	out = append(out, addr.Diversifier[:]...)
+	return append(out, pkd...), nil
+}
+
+// PaymentAddressFromBytes decodes a 43-byte payment address. The diversifier
+// must be valid, and pk_d must be a canonically encoded point in the
+// prime-order subgroup other than the identity.
+func (curve *Jubjub) PaymentAddressFromBytes(in []byte) (*PaymentAddress, error) {
+	if len(in) != PaymentAddressSize {
+		return nil, ErrInvalidAddress
+	}
+
+	addr := &PaymentAddress{}
+	copy(addr.Diversifier[:], in[:DiversifierLength])
+	if _, err := curve.DiversifyHash(addr.Diversifier); err != nil {
+		return nil, ErrInvalidAddress
+	}
+
+	pkd, err := curve.Decompress(in[DiversifierLength:])
+	if err != nil || !pkd.inPrimeSubgroup() || pkd.IsIdentity() {
+		return nil, ErrInvalidAddress
+	}
+	addr.PkD = pkd
+
+	return addr, nil
+}
+
+// IncomingViewingKey is a Sapling incoming viewing key, which can derive payment
+// addresses and detect notes sent to them.
+type IncomingViewingKey struct {
+	curve *Jubjub
+	ivk   *Scalar
+}
+
+// NewIncomingViewingKey wraps an ivk scalar, such as one returned by DeriveIvk.
+// It returns an error if ivk is zero.
+func (curve *Jubjub) NewIncomingViewingKey(ivk *Scalar) (*IncomingViewingKey, error) {
+	if ivk.n.Sign() == 0 {
+		return nil, ErrInvalidIvk
+	}
+	sc, _ := newScalar(nil, curve.subgroupOrder)
+	sc.n.Set(ivk.n)
+	return &IncomingViewingKey{curve, sc}, nil
+}
+
+// Scalar returns the ivk as a newly allocated scalar.
+func (ivk *IncomingViewingKey) Scalar() *Scalar {
+	sc, _ := newScalar(nil, ivk.curve.subgroupOrder)
+	sc.n.Set(ivk.ivk.n)
+	return sc
+}
+
+// Address returns the payment address for the diversifier d, with pk_d = [ivk] g_d.
+// It returns ErrInvalidDiversifier if d has no diversified base.
+func (ivk *IncomingViewingKey) Address(d Diversifier) (*PaymentAddress, error) {
+	gd, err := ivk.curve.DiversifyHash(d)
+	if err != nil {
+		return nil, err
+	}
+
+	pkd, err := ivk.curve.ScalarMult(ivk.ivk, gd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaymentAddress{d, pkd}, nil
+}
+
+// FindAddress searches upward from index for the first index that is a valid
+// diversifier and returns its address along with the index used. The index is
+// used directly as the diversifier bytes; keys derived under ZIP 32 should
+// instead search with their diversifier key.
+func (ivk *IncomingViewingKey) FindAddress(index DiversifierIndex) (*PaymentAddress, DiversifierIndex, error) {
+	for {
+		addr, err := ivk.Address(Diversifier(index))
+		if err == nil {
+			return addr, index, nil
+		}
+		if err != ErrInvalidDiversifier {
+			return nil, index, err
+		}
+		if !index.increment() {
+			return nil, index, ErrDiversifierSpaceExhausted
+		}
+	}
+}
@@ -0,0 +1,663 @@
+package jubjub
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// From https://github.com/zcash/zcash-test-vectors/blob/master/zcash_test_vectors/sapling/key_components.py
+var saplingKeyComponents = []struct {
+	sk, ask, nsk, ovk, ak, nk, ivk string
+	defaultD, defaultPkD           string
+	noteV                          uint64
+	noteR, noteCmu                 string
+	notePos                        uint64
+	noteNf                         string
+}{
+	{
+		sk:         "0000000000000000000000000000000000000000000000000000000000000000",
+		ask:        "8548a14a473ea547aa2378402044f818cf1911cf5dd2054f678345f00d0e8806",
+		nsk:        "30114ea0dd0bb61cf0eaeab6ec3331f581b0425e27338501262d7eac745e6e05",
+		ovk:        "98d16913d99b04177caba44f6e4d224e03b5ac031d7ce45e865138e1b996d63b",
+		ak:         "f344ec380fe1273e3098c2588c5d3a791fd7ba958032760777fd0efa8ef11620",
+		nk:         "f7cf9e77f2e58683383c1519ac7b062d30040e27a725fb88fb19a978bd3fd6ba",
+		ivk:        "b70b7cd0ed03cbdfd7ada9502ee245b13e569d54a5719d2daa0f5f1451479204",
+		defaultD:   "f19d9b797e39f337445839",
+		defaultPkD: "db4cd2b0aac4f7eb8ca131f16567c445a9555126d3c29f14e3d776e841ae7415",
+		noteV:      0,
+		noteR:      "39176dac39ace4980ecc8d778e89860255ec3615060000000000000000000000",
+		noteCmu:    "cb3cf9153270d57eb914c6c2bcc01850c9fed44fce0806278f083ef2dd076439",
+		notePos:    0,
+		noteNf:     "44fad6564ffdec9fa19c43a28f861d5ebf602346007de76267d9752747ab4063",
+	},
+	{
+		sk:         "0101010101010101010101010101010101010101010101010101010101010101",
+		ask:        "c9435629bf8bffe55e7335ec077718ba60ba28d7ac3794b74f512c31af0a5304",
+		nsk:        "11acc2ead07b5f008c1f0f090cc8ddf335236ff4b253c6495695e9d639dacd08",
+		ovk:        "3b946210ce6d1b1692d7392ac84a8bc8f03b72723c7d36721b809a79c9d6e45b",
+		ak:         "82ff5effc527ae84020bf2d35201c10219131947ff4b96f881a45f2e8ae30518",
+		nk:         "c4534d848bb918cf4a7f8b98740ab3ccee586795ff4df64547a8888a6c7415d2",
+		ivk:        "c518384466b26988b5109067418d192d9d6bd0d9232205d77418c240fc68a406",
+		defaultD:   "aef180f6e34e354b888f81",
+		defaultPkD: "a6b13ea336ddb7a67bb09a0e68e9d3cfb39210831ea3a296ba09a922060fd38b",
+		noteV:      12227227834928555328,
+		noteR:      "478ba0ee6e1a75b600036f26f18b7015ab556beddf8b960238869f89dd804e06",
+		noteCmu:    "b57893500bfb85df2e8b01ac452f89e10e266bcfa31c31b29a53ae72cad46950",
+		notePos:    763714296,
+		noteNf:     "679eb0c3a757e2ae83cdb42a1ab259d78388315419adc71d2e3763174c2e9d93",
+	},
+	{
+		sk:         "0202020202020202020202020202020202020202020202020202020202020202",
+		ask:        "ee1c3d7efe0a78063d6af3d9d81212af47b7c1b761f85ccb066fc11a6a421703",
+		nsk:        "1d3b713755d74875e8ea38fd166e76c62a4250216e6bbfe48a5e2eabad117f0b",
+		ovk:        "8bf4390e28ddc95b8302c381d5810b84ba8e6096e5a76822774fd49f491e8f49",
+		ak:         "ab83574eb5de859a0ab8629dec34c7bee8c3fc74dfa0b19a3a7468d15dca64c6",
+		nk:         "95d58053e0592e4a169cc0b7928aaac3de24ef1531aa9eb6f4ab93914da8a06e",
+		ivk:        "471c24a3dc8730e75036c0a95f3e2f7dd1be6fb93ad29592203def3041954505",
+		defaultD:   "7599f0bf9b57cd2dc299b6",
+		defaultPkD: "66141739514b28f05def8a18eeee5eed4d44c6225c3c65d88dd9907708012f5a",
+		noteV:      6007711596147559040,
+		noteR:      "147cf2b51b4c7c63cb77b99e8b783e5b5111db0a7ca04d6c014a1d7da83bae0a",
+		noteCmu:    "db85a70a98437f73167fc332d5b7b7408296661770b101b0aa87839f4e55f151",
+		notePos:    1527428592,
+		noteNf:     "e98f6a8f34ff498059b3c731b91f451108c4954d919484361cf9b48f59ae1d14",
+	},
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestDeriveIvk(t *testing.T) {
+	curve := Curve()
+	for i, tv := range saplingKeyComponents {
+		ak, err := curve.Decompress(decodeHex(t, tv.ak))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nk, err := curve.Decompress(decodeHex(t, tv.nk))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ivk, err := curve.DeriveIvk(ak, nk)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := decodeHex(t, tv.ivk); !bytes.Equal(ivk.ToBytes(), want) {
+			t.Errorf("Incorrect ivk for test %d:\nWant: %x\nHave: %x", i, want, ivk.ToBytes())
+		}
+	}
+}
+
+func TestFindGroupHash(t *testing.T) {
+	// From https://github.com/zcash/zcash-test-vectors/blob/master/zcash_test_vectors/sapling/generators.py
+	generators := []struct {
+		personalization string
+		msg             []byte
+		repr            string
+	}{
+		{"Zcash_G_", nil, "30b5f2aaad325630bcdddbce4d67656d05fd1cc2d037bb5375b6e96d9e01a1d7"},
+		{"Zcash_H_", nil, "e7e85de0f7f97a46d249a1f5ea51df50cc48490f8401c9de7a2adf1807d1b6d4"},
+		{"Zcash_J_", nil, "65002bc736faf7a3422effffe8b855e18fba96a0158a9efca584bf40549d36e1"},
+		{"Zcash_PH", []byte("r"), "ac776c796563fcd44cc49cfaea8bb796952c266e47779d94574c10ad01754b11"},
+		{"Zcash_cv", []byte("v"), "d7c86706f5817aa718cd1cfad03233bcd64a7789fd9422d3b17af6823a7e6ac6"},
+		{"Zcash_cv", []byte("r"), "8b6a0b38b9faae3c3b803b47b0f146ad50ab221e6e2afbe6dbde45cba9d381ed"},
+		{"Zcash_PH", []byte{0, 0, 0, 0}, "ca3c2432d4abbf7732464ec08b2e47f95edc7e836b16c979571b52d3a2879ea8"},
+		{"Zcash_PH", []byte{1, 0, 0, 0}, "9118bf4e3cc50d7be8d3fa98ebbe3a1f25d901c0421189f733fe435b7f8c5d01"},
+	}
+
+	curve := Curve()
+	for i, tv := range generators {
+		p, err := curve.FindGroupHash(tv.personalization, tv.msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := decodeHex(t, tv.repr); !bytes.Equal(p.Compress(), want) {
+			t.Errorf("Incorrect generator for test %d:\nWant: %x\nHave: %x", i, want, p.Compress())
+		}
+	}
+}
+
+func TestProofGenerationKey(t *testing.T) {
+	curve := Curve()
+
+	// The generators are fresh copies, so changing one doesn't affect the cache.
+	g := curve.SpendingKeyGenerator()
+	g.Double(g)
+	if want := decodeHex(t, "30b5f2aaad325630bcdddbce4d67656d05fd1cc2d037bb5375b6e96d9e01a1d7"); !bytes.Equal(curve.SpendingKeyGenerator().Compress(), want) {
+		t.Error("Incorrect spending key generator")
+	}
+	if want := decodeHex(t, "e7e85de0f7f97a46d249a1f5ea51df50cc48490f8401c9de7a2adf1807d1b6d4"); !bytes.Equal(curve.ProofGenerationKeyGenerator().Compress(), want) {
+		t.Error("Incorrect proof generation key generator")
+	}
+
+	for i, tv := range saplingKeyComponents {
+		ask, _ := curve.ScalarFromBytes(decodeHex(t, tv.ask))
+		nsk, _ := curve.ScalarFromBytes(decodeHex(t, tv.nsk))
+
+		pgk, err := curve.DeriveProofGenerationKey(&ExpandedSpendingKey{Ask: ask, Nsk: nsk})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := decodeHex(t, tv.ak); !bytes.Equal(pgk.Ak.Compress(), want) {
+			t.Errorf("Incorrect ak for test %d:\nWant: %x\nHave: %x", i, want, pgk.Ak.Compress())
+		}
+
+		vk, err := pgk.ViewingKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := decodeHex(t, tv.nk); !bytes.Equal(vk.Nk.Compress(), want) {
+			t.Errorf("Incorrect nk for test %d:\nWant: %x\nHave: %x", i, want, vk.Nk.Compress())
+		}
+
+		ivk, err := vk.IncomingViewingKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := decodeHex(t, tv.ivk); !bytes.Equal(ivk.Scalar().ToBytes(), want) {
+			t.Errorf("Incorrect ivk for test %d:\nWant: %x\nHave: %x", i, want, ivk.Scalar().ToBytes())
+		}
+	}
+}
+
+func TestPaymentAddress(t *testing.T) {
+	curve := Curve()
+	for i, tv := range saplingKeyComponents {
+		sc, _ := curve.ScalarFromBytes(decodeHex(t, tv.ivk))
+		ivk, err := curve.NewIncomingViewingKey(sc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var d Diversifier
+		copy(d[:], decodeHex(t, tv.defaultD))
+
+		addr, err := ivk.Address(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := decodeHex(t, tv.defaultPkD); !bytes.Equal(addr.PkD.Compress(), want) {
+			t.Errorf("Incorrect pk_d for test %d:\nWant: %x\nHave: %x", i, want, addr.PkD.Compress())
+		}
+
+		// Searching from a valid diversifier should return it unchanged.
+		found, index, err := ivk.FindAddress(DiversifierIndex(d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if Diversifier(index) != d || !found.PkD.Equals(addr.PkD) {
+			t.Errorf("FindAddress skipped a valid diversifier for test %d", i)
+		}
+	}
+}
+
+func TestFindAddressSkipsInvalid(t *testing.T) {
+	curve := Curve()
+	sc, _ := curve.ScalarFromBytes(decodeHex(t, saplingKeyComponents[0].ivk))
+	ivk, _ := curve.NewIncomingViewingKey(sc)
+
+	// Find an invalid diversifier to start from.
+	var start DiversifierIndex
+	for {
+		if _, err := curve.DiversifyHash(Diversifier(start)); err == ErrInvalidDiversifier {
+			break
+		}
+		start.increment()
+	}
+
+	addr, index, err := ivk.FindAddress(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index == start {
+		t.Fatal("FindAddress returned an invalid diversifier")
+	}
+	if _, err := curve.DiversifyHash(addr.Diversifier); err != nil {
+		t.Fatal("FindAddress returned an address with an invalid diversifier")
+	}
+}
+
+// From https://github.com/zcash/zcash-test-vectors/blob/master/zcash_test_vectors/sapling/note_encryption.py
+var saplingNoteEncryption = []struct {
+	ivk, defaultD, defaultPkD string
+	esk, epk, sharedSecret    string
+	v                         uint64
+	rcm, cmu, cEnc            string
+	ovk, cv, cOut             string
+}{
+	{
+		ivk:          "b70b7cd0ed03cbdfd7ada9502ee245b13e569d54a5719d2daa0f5f1451479204",
+		defaultD:     "f19d9b797e39f337445839",
+		defaultPkD:   "db4cd2b0aac4f7eb8ca131f16567c445a9555126d3c29f14e3d776e841ae7415",
+		esk:          "81c7b2171ff4415250cac01f5982fd8f49619d61ad78f6830b3c606145962a0e",
+		epk:          "ded68f05c658fcae5ae218646ff844406f84426784040d0bef2b09cb3848c4dc",
+		sharedSecret: "67f9613404d9e9271f1674011b039b3d4381a4d70c586c8a1342283fd5fc3ade",
+		v:            100000000,
+		rcm:          "39176dac39ace4980ecc8d778e89860255ec3615060000000000000000000000",
+		cmu:          "635572f572a8a1a0b7acbc0afc6d66f14a02efacde7bdf03443ed4c3e551d470",
+		cEnc:         "8d6b27e7eff59bfba01d6588badd366ce59b4d5b0ef93bebcbf211417c56ae700ae18244bac2fb6437db01f83dc149e2786ec4ec32c11b054a4c0e2bdbe343788bb9c33ff42fae99323213e0963e6f976d6fffb8c9fcf5219574c7a94c0e72f6093aedafe380621b3ba815d2b97240f677d390f5fc5d45eeff16688e40b9eee8ee1d393b009750cb73df7a47fd07a28141db49bd9ccab1f18d0b6a55ed101ca16f7345bcb0beaf7cd79a3d2bf288f1d88ebb1e4b742199d330c30a9fee1b44c686a1ff5cc33d4627f83d61ce34d6f1344e2b11a5f7172442296075919005434a574ed4e4c98e238edd5367e8f57524b638dd2d5830e83f7f32080d2d51a08ae84e37429c8438faae1540867b12ac2cf6a77da780d92cfa500c195a071ce8ae3f102ce09501ecdac08a7952a08d53f362d37b64948c9915cbfc9f2d3c4e8222d39a348421447fabe4d5f087809a79e849b28dffbc97fbbf647ff34f79ff64e737ebf03d8add44c154325f2bff14c6e9e90b0f9889f325a926a3685641a7a219ece6fb2b4deebf3109d7ee0f039dac427444993485848444ccafda5ea328740666dd75c323ce7b920ee0f3dc3abce6bd09c13c957c5ea8952827116bb5bd0e5c27f820f2cf72a5105d9555be1e1e5e68fffb7133dc3900194e3b731c7d391170ad6d4af13a78a06c25cfbb0d0991d5a883cff51cb6f591c792d99dcc559cde9b7b39c4f54a6bfb29f1f85e135d1733b49d5dd67018e62e8c1ab0c19a25418726ccf2f5e88b97692112924bda2fde7348bad7295241729db4f38711c7ea98c5d4197c66fd23",
+		ovk:          "98d16913d99b04177caba44f6e4d224e03b5ac031d7ce45e865138e1b996d63b",
+		cv:           "a9cb0d137232ff8448d0f078b6814c66cb331b0f2d3d8a085bedba815f00a8db",
+		cOut:         "0eb2b01be8880fc0469842271418b52bad4019892cde53eecacdb2e45f5f337585f7f6175d888f6e2c4ed13571cd96fd177a01ab101908d7ca4a6d81d916622f5ff077b13f345590e227c10e0895e204",
+	},
+	{
+		ivk:          "c518384466b26988b5109067418d192d9d6bd0d9232205d77418c240fc68a406",
+		defaultD:     "aef180f6e34e354b888f81",
+		defaultPkD:   "a6b13ea336ddb7a67bb09a0e68e9d3cfb39210831ea3a296ba09a922060fd38b",
+		esk:          "ad4ad62477c2c883c8babfed5d385b51abdcc698e936e78dc22671729155620b",
+		epk:          "f06cbaf8cb5c84823847a120104c85ad707228adba876c6d837efd414e1c1db4",
+		sharedSecret: "b98a2c3bf0dc56b2bf65f5bd1525055eed22ac0dcc2c11e300c467802b858897",
+		v:            200000000,
+		rcm:          "478ba0ee6e1a75b600036f26f18b7015ab556beddf8b960238869f89dd804e06",
+		cmu:          "0c87417577480b6977ba92c55425d62b03b1e5f3c3829cac49bfe515ae722945",
+		cEnc:         "8a3f60252f4df996392e55afee0722f124b1a134e8a1fb1eaa88889e6ad489cf1ba91255ee56fa1a09db7156c3551aed2969a6ff37f2a77a60b3ea4375faff049e85c27221cc2ba989bd18ff9698000af1a7643f8785d65ebb04c85b2475df625b47e3e9c7aca84c131723776bd8c29f9d1f5fd257e58f72b604f9b57b1c2d0531ebbb19cfc27368890d256e9aba308db9d8856f49d4663afe555072ed64c8198e6ad15c0c43bb168549a5be38c5b46dc12f0c2a961ff3cfe32a1c3efe80b15e37e4cebe2a7abe03eb17f4bbad2231cb5255e29cd03cb961332cf5e55e6053cd4065c3785606b2185f18c4a3a22623d259cd20dbe154c4af6b2bdcf3b9c0ff13ce27e39505a9f1b82f6fceeac095384717e8970ee029de964e804abd32d4da93bb8dc2b6bd6044d8dfd79df7207ea03bdf036fa6263f21bc1bfd4a6d9cb5f2d8bb6e74b6dd047ae1aab8c1a723b4787c54e253967fa9440b736183506574350355269b2b66b748e88fe9b8d123e94b5fa5d072b8c39652e9202b16f165460e4b970f63ee7d638f48e49017ea641cd37009d44b7724182544db92bd0c4a7e9d9393d46fcb7bddf96f02cbf47fa0f52804098ecbbb7a13f3a2a5f1638e77f8a82f6c3decb7607f0951c57c7f2776042214f90a3b6e00ed16059dff4555bd471d78afe7aa3dc79141a0872d19c81c351caf54a2fc6de8fd7686c4f2c534efac77515e30f2507ba0b23b1ee37ca908943dfef3809a7e9becf1b9691049f7876a592ee7ed64740f1be7e3066ef76f81470f4354331aa1bc49579699697782bb075cbf82d3a8c0",
+		ovk:          "3b946210ce6d1b1692d7392ac84a8bc8f03b72723c7d36721b809a79c9d6e45b",
+		cv:           "fc54319a39be49c0480c4df33b8f77ca673a42bfdedfb80ee46b8f70fc0dcd3d",
+		cOut:         "882458302c0aba55ed8d6718ca26d8c28a127a01e77c2ae5bf15c69673918177f92477a218a7f6cf12178022c9ddc7185c18d0876c3c296583e0bc54793bf1e26a854a41ab617f205271ba6c1429bdf4",
+	},
+}
+
+func TestKeyAgreement(t *testing.T) {
+	curve := Curve()
+	for i, tv := range saplingNoteEncryption {
+		var d Diversifier
+		copy(d[:], decodeHex(t, tv.defaultD))
+		gd, err := curve.DiversifyHash(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkd, _ := curve.Decompress(decodeHex(t, tv.defaultPkD))
+		esk, _ := curve.ScalarFromBytes(decodeHex(t, tv.esk))
+		ivk, _ := curve.ScalarFromBytes(decodeHex(t, tv.ivk))
+
+		epk, err := curve.KADerivePublic(esk, gd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := decodeHex(t, tv.epk); !bytes.Equal(epk.Compress(), want) {
+			t.Errorf("Incorrect epk for test %d:\nWant: %x\nHave: %x", i, want, epk.Compress())
+		}
+
+		want := decodeHex(t, tv.sharedSecret)
+
+		senderShared, err := curve.KAAgree(esk, pkd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(senderShared.Compress(), want) {
+			t.Errorf("Incorrect sender shared secret for test %d:\nWant: %x\nHave: %x", i, want, senderShared.Compress())
+		}
+
+		recipientShared, err := curve.KAAgree(ivk, epk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(recipientShared.Compress(), want) {
+			t.Errorf("Incorrect recipient shared secret for test %d:\nWant: %x\nHave: %x", i, want, recipientShared.Compress())
+		}
+	}
+}
+
+func TestKeyAgreementRejectsSmallOrder(t *testing.T) {
+	curve := Curve()
+	ivk, _ := curve.ScalarFromBytes(decodeHex(t, saplingNoteEncryption[0].ivk))
+
+	// (0, -1) has order 2.
+	minusOne := curve.newFieldElement(nil).Sub(curve.fieldZero, curve.fieldOne)
+	smallOrder, err := curve.Decompress(minusOne.ToBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := curve.KAAgree(ivk, smallOrder); err != ErrIdentity {
+		t.Error("Accepted a small-order public key")
+	}
+	if _, err := curve.KAAgree(ivk, curve.Identity()); err != ErrIdentity {
+		t.Error("Accepted the identity as a public key")
+	}
+}
+
+func TestNoteCommitment(t *testing.T) {
+	curve := Curve()
+	for i, tv := range saplingKeyComponents {
+		var d Diversifier
+		copy(d[:], decodeHex(t, tv.defaultD))
+		pkd, _ := curve.Decompress(decodeHex(t, tv.defaultPkD))
+
+		note := &Note{
+			Recipient: &PaymentAddress{d, pkd},
+			Value:     tv.noteV,
+			LeadByte:  NoteLeadByteV1,
+		}
+		copy(note.Rseed[:], decodeHex(t, tv.noteR))
+
+		cm, err := note.Commitment()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := decodeHex(t, tv.noteCmu); !bytes.Equal(cm.ExtractJ(), want) {
+			t.Errorf("Incorrect cmu for test %d:\nWant: %x\nHave: %x", i, want, cm.ExtractJ())
+		}
+	}
+}
+
+func TestNullifier(t *testing.T) {
+	curve := Curve()
+	for i, tv := range saplingKeyComponents {
+		var d Diversifier
+		copy(d[:], decodeHex(t, tv.defaultD))
+		pkd, _ := curve.Decompress(decodeHex(t, tv.defaultPkD))
+		nk, _ := curve.Decompress(decodeHex(t, tv.nk))
+
+		note := &Note{
+			Recipient: &PaymentAddress{d, pkd},
+			Value:     tv.noteV,
+			LeadByte:  NoteLeadByteV1,
+		}
+		copy(note.Rseed[:], decodeHex(t, tv.noteR))
+
+		cm, err := note.Commitment()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		nf := Nullifier(nk, cm, tv.notePos)
+		if want := decodeHex(t, tv.noteNf); !bytes.Equal(nf[:], want) {
+			t.Errorf("Incorrect nullifier for test %d:\nWant: %x\nHave: %x", i, want, nf)
+		}
+	}
+}
+
+func TestTryDecryptNote(t *testing.T) {
+	curve := Curve()
+	for i, tv := range saplingNoteEncryption {
+		sc, _ := curve.ScalarFromBytes(decodeHex(t, tv.ivk))
+		ivk, _ := curve.NewIncomingViewingKey(sc)
+		epk, _ := curve.Decompress(decodeHex(t, tv.epk))
+		cmu := decodeHex(t, tv.cmu)
+		cEnc := decodeHex(t, tv.cEnc)
+
+		note, memo, err := TryDecryptNote(ivk, epk, cmu, cEnc)
+		if err != nil {
+			t.Fatalf("Couldn't decrypt test %d: %v", i, err)
+		}
+
+		if note.Value != tv.v {
+			t.Errorf("Incorrect value for test %d: want %d, have %d", i, tv.v, note.Value)
+		}
+		if want := decodeHex(t, tv.rcm); !bytes.Equal(note.Rseed[:], want) {
+			t.Errorf("Incorrect rcm for test %d:\nWant: %x\nHave: %x", i, want, note.Rseed)
+		}
+		if want := decodeHex(t, tv.defaultPkD); !bytes.Equal(note.Recipient.PkD.Compress(), want) {
+			t.Errorf("Incorrect pk_d for test %d:\nWant: %x\nHave: %x", i, want, note.Recipient.PkD.Compress())
+		}
+		if memo[0] != 0xf6 || !bytes.Equal(memo[1:], make([]byte, MemoSize-1)) {
+			t.Errorf("Incorrect memo for test %d", i)
+		}
+
+		// A different cmu must be rejected even though the ciphertext opens.
+		badCmu := append([]byte{}, cmu...)
+		badCmu[0] ^= 1
+		if _, _, err := TryDecryptNote(ivk, epk, badCmu, cEnc); err != ErrInvalidNotePlaintext {
+			t.Errorf("Accepted the wrong cmu for test %d", i)
+		}
+
+		// Another key's ivk must not decrypt the note.
+		other := saplingNoteEncryption[(i+1)%len(saplingNoteEncryption)]
+		otherSc, _ := curve.ScalarFromBytes(decodeHex(t, other.ivk))
+		otherIvk, _ := curve.NewIncomingViewingKey(otherSc)
+		if _, _, err := TryDecryptNote(otherIvk, epk, cmu, cEnc); err != ErrNoteDecryptionFailed {
+			t.Errorf("Decrypted test %d with the wrong ivk", i)
+		}
+	}
+}
+
+func TestTryRecoverOutputNote(t *testing.T) {
+	curve := Curve()
+	for i, tv := range saplingNoteEncryption {
+		var ovk OutgoingViewingKey
+		copy(ovk[:], decodeHex(t, tv.ovk))
+		cv, _ := curve.Decompress(decodeHex(t, tv.cv))
+		epk, _ := curve.Decompress(decodeHex(t, tv.epk))
+		cmu := decodeHex(t, tv.cmu)
+		cEnc := decodeHex(t, tv.cEnc)
+		cOut := decodeHex(t, tv.cOut)
+
+		note, memo, err := TryRecoverOutputNote(ovk, cv, cmu, epk, cEnc, cOut)
+		if err != nil {
+			t.Fatalf("Couldn't recover test %d: %v", i, err)
+		}
+
+		if note.Value != tv.v {
+			t.Errorf("Incorrect value for test %d: want %d, have %d", i, tv.v, note.Value)
+		}
+		if want := decodeHex(t, tv.defaultD); !bytes.Equal(note.Recipient.Diversifier[:], want) {
+			t.Errorf("Incorrect diversifier for test %d:\nWant: %x\nHave: %x", i, want, note.Recipient.Diversifier)
+		}
+		if want := decodeHex(t, tv.defaultPkD); !bytes.Equal(note.Recipient.PkD.Compress(), want) {
+			t.Errorf("Incorrect pk_d for test %d:\nWant: %x\nHave: %x", i, want, note.Recipient.PkD.Compress())
+		}
+		if memo[0] != 0xf6 {
+			t.Errorf("Incorrect memo for test %d", i)
+		}
+
+		// ock binds cv, so recovery must fail if it changes.
+		if _, _, err := TryRecoverOutputNote(ovk, curve.SubgroupGenerator(), cmu, epk, cEnc, cOut); err != ErrNoteDecryptionFailed {
+			t.Errorf("Recovered test %d with the wrong cv", i)
+		}
+
+		ovk[0] ^= 1
+		if _, _, err := TryRecoverOutputNote(ovk, cv, cmu, epk, cEnc, cOut); err != ErrNoteDecryptionFailed {
+			t.Errorf("Recovered test %d with the wrong ovk", i)
+		}
+	}
+}
+
+func TestNoteEncryptor(t *testing.T) {
+	curve := Curve()
+	for i, tv := range saplingNoteEncryption {
+		var ovk OutgoingViewingKey
+		copy(ovk[:], decodeHex(t, tv.ovk))
+		var d Diversifier
+		copy(d[:], decodeHex(t, tv.defaultD))
+		pkd, _ := curve.Decompress(decodeHex(t, tv.defaultPkD))
+		cv, _ := curve.Decompress(decodeHex(t, tv.cv))
+		cmu := decodeHex(t, tv.cmu)
+
+		note := &Note{
+			Recipient: &PaymentAddress{d, pkd},
+			Value:     tv.v,
+			LeadByte:  NoteLeadByteV1,
+		}
+		copy(note.Rseed[:], decodeHex(t, tv.rcm))
+
+		var memo Memo
+		memo[0] = 0xf6
+
+		// The test vectors sampled esk directly, which the wide reduction reproduces when the high half is zero.
+		randomness := append(decodeHex(t, tv.esk), make([]byte, 32)...)
+		ne, err := NewNoteEncryptor(&ovk, note, &memo, bytes.NewReader(randomness))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := decodeHex(t, tv.epk); !bytes.Equal(ne.Epk().Compress(), want) {
+			t.Errorf("Incorrect epk for test %d:\nWant: %x\nHave: %x", i, want, ne.Epk().Compress())
+		}
+
+		cEnc, err := ne.EncryptNotePlaintext()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := decodeHex(t, tv.cEnc); !bytes.Equal(cEnc, want) {
+			t.Errorf("Incorrect encCiphertext for test %d:\nWant: %x\nHave: %x", i, want, cEnc)
+		}
+
+		cOut, err := ne.EncryptOutgoingPlaintext(cv, cmu)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := decodeHex(t, tv.cOut); !bytes.Equal(cOut, want) {
+			t.Errorf("Incorrect outCiphertext for test %d:\nWant: %x\nHave: %x", i, want, cOut)
+		}
+	}
+}
+
+func TestNoteEncryptionRoundtripZIP212(t *testing.T) {
+	curve := Curve()
+	tv := saplingNoteEncryption[0]
+
+	sc, _ := curve.ScalarFromBytes(decodeHex(t, tv.ivk))
+	ivk, _ := curve.NewIncomingViewingKey(sc)
+	var d Diversifier
+	copy(d[:], decodeHex(t, tv.defaultD))
+	addr, err := ivk.Address(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	note := &Note{Recipient: addr, Value: 12345, LeadByte: NoteLeadByteV2}
+	copy(note.Rseed[:], bytes.Repeat([]byte{0x42}, 32))
+	cm, err := note.Commitment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmu := cm.ExtractJ()
+
+	var ovk OutgoingViewingKey
+	copy(ovk[:], decodeHex(t, tv.ovk))
+	var memo Memo
+	copy(memo[:], "hello")
+
+	ne, err := NewNoteEncryptor(&ovk, note, &memo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ne.Esk().Equals(note.deriveEsk()) {
+		t.Fatal("esk was not derived from rseed")
+	}
+
+	cv := curve.SubgroupGenerator()
+	cEnc, _ := ne.EncryptNotePlaintext()
+	cOut, _ := ne.EncryptOutgoingPlaintext(cv, cmu)
+
+	decrypted, decryptedMemo, err := TryDecryptNote(ivk, ne.Epk(), cmu, cEnc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decrypted.Value != note.Value || decrypted.Rseed != note.Rseed || *decryptedMemo != memo {
+		t.Error("Decrypted a different note than was encrypted")
+	}
+
+	recovered, _, err := TryRecoverOutputNote(ovk, cv, cmu, ne.Epk(), cEnc, cOut)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered.Value != note.Value || !recovered.Recipient.PkD.Equals(addr.PkD) {
+		t.Error("Recovered a different note than was encrypted")
+	}
+
+	// Under ZIP 212 epk must be the one derived from rseed.
+	if _, _, err := TryDecryptNote(ivk, cv, cmu, cEnc); err == nil {
+		t.Error("Decrypted a note under the wrong epk")
+	}
+
+	// A nil memo is encrypted as the "no memo" value.
+	ne, err = NewNoteEncryptor(&ovk, note, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cEnc, _ = ne.EncryptNotePlaintext()
+	_, decryptedMemo, err = TryDecryptNote(ivk, ne.Epk(), cmu, cEnc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var noMemo Memo
+	noMemo[0] = 0xf6
+	if *decryptedMemo != noMemo {
+		t.Errorf("Nil memo decrypted as %x", decryptedMemo[:4])
+	}
+}
+
+func TestBatchScanner(t *testing.T) {
+	curve := Curve()
+
+	var ivks []*IncomingViewingKey
+	var outputs []CompactOutput
+	for _, tv := range saplingNoteEncryption {
+		sc, _ := curve.ScalarFromBytes(decodeHex(t, tv.ivk))
+		ivk, _ := curve.NewIncomingViewingKey(sc)
+		ivks = append(ivks, ivk)
+
+		var out CompactOutput
+		copy(out.Epk[:], decodeHex(t, tv.epk))
+		copy(out.Cmu[:], decodeHex(t, tv.cmu))
+		copy(out.Ciphertext[:], decodeHex(t, tv.cEnc))
+		outputs = append(outputs, out)
+	}
+
+	// An output with an undecodable epk, one with a small-order epk, and one
+	// that belongs to nobody.
+	junk := outputs[0]
+	for i := range junk.Epk {
+		junk.Epk[i] = 0xff
+	}
+	smallOrder := outputs[0]
+	copy(smallOrder.Epk[:], curve.newFieldElement(nil).Sub(curve.fieldZero, curve.fieldOne).ToBytes())
+	stranger := outputs[1]
+	stranger.Cmu[0] ^= 1
+	outputs = append([]CompactOutput{junk}, outputs...)
+	outputs = append(outputs, smallOrder, stranger)
+
+	for _, workers := range []int{0, 1, 4} {
+		results := NewBatchScanner(ivks, workers).Scan(outputs)
+		if len(results) != len(saplingNoteEncryption) {
+			t.Fatalf("Expected %d matches with %d workers, found %d", len(saplingNoteEncryption), workers, len(results))
+		}
+
+		for i, r := range results {
+			tv := saplingNoteEncryption[i]
+			if r.OutputIndex != i+1 || r.IvkIndex != i {
+				t.Errorf("Incorrect indices for match %d: output %d, ivk %d", i, r.OutputIndex, r.IvkIndex)
+			}
+			if r.Note.Value != tv.v {
+				t.Errorf("Incorrect value for match %d: want %d, have %d", i, tv.v, r.Note.Value)
+			}
+			if want := decodeHex(t, tv.rcm); !bytes.Equal(r.Note.Rseed[:], want) {
+				t.Errorf("Incorrect rcm for match %d", i)
+			}
+		}
+	}
+
+	// Enough outputs to span several batches, with a match in each position
+	// of a batch.
+	var many []CompactOutput
+	for len(many) < 3*scanBatchSize+5 {
+		many = append(many, outputs[1], junk, smallOrder)
+	}
+	results := NewBatchScanner(ivks[:1], 3).Scan(many)
+	if len(results) != (len(many)+2)/3 {
+		t.Fatalf("Expected %d matches across batches, found %d", (len(many)+2)/3, len(results))
+	}
+	for i, r := range results {
+		if r.OutputIndex != 3*i || r.Note.Value != saplingNoteEncryption[0].v {
+			t.Errorf("Incorrect match %d across batches: output %d", i, r.OutputIndex)
+		}
+	}
+
+	if results := NewBatchScanner(nil, 2).Scan(outputs); len(results) != 0 {
+		t.Errorf("Found %d matches without any keys", len(results))
+	}
+}
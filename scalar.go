@@ -18,13 +18,14 @@ type Scalar struct {
 }
 
 // newScalar returns n mod order. It additionally returns an out-of-range error
-// if the value needed to be reduced.
+// if the value needed to be reduced, that is, if n is negative or not less
+// than order.
 func newScalar(n, order *big.Int) (*Scalar, error) {
 	if n == nil {
 		n = new(big.Int)
 	}
 
-	if n.Cmp(order) == 1 || n.Cmp(big.NewInt(0)) == -1 {
+	if n.Cmp(order) != -1 || n.Cmp(big.NewInt(0)) == -1 {
 		n.Mod(n, order)
 		return &Scalar{n, order}, ErrScalarOutOfRange
 	}
@@ -32,14 +33,30 @@ func newScalar(n, order *big.Int) (*Scalar, error) {
 	return &Scalar{n, order}, nil
 }
 
-// ScalarFromBytes reads a scalar value from little-endian bytes and returns
-// it. If the value of the Int is outside the order of the subgroup, ScalarFromBytes
-// reduces it.
+// ScalarFromBytes reads a scalar value from its canonical little-endian
+// encoding, which is as long as ToBytes returns, and returns it. If the value
+// is outside the order of the subgroup, ScalarFromBytes reduces it and
+// additionally returns ErrScalarOutOfRange. Input of any other length returns
+// ErrScalarOutOfRange and no scalar; use ScalarFromUniformBytes to reduce hash
+// outputs.
 func (curve *Jubjub) ScalarFromBytes(in []byte) (*Scalar, error) {
+	if len(in) != (curve.subgroupOrder.BitLen()+7)/8 {
+		return nil, ErrScalarOutOfRange
+	}
 	sc, _ := newScalar(nil, curve.subgroupOrder)
 	return sc.fromBytes(in)
 }
 
+// ScalarFromUniformBytes reduces a little-endian bytestring of any length
+// modulo the order of the subgroup. A uniformly random 64-byte string maps to
+// a nearly uniform scalar, which is how Sapling derives scalars from PRF and
+// hash outputs.
+func (curve *Jubjub) ScalarFromUniformBytes(in []byte) *Scalar {
+	sc, _ := newScalar(nil, curve.subgroupOrder)
+	sc.fromBytes(in)
+	return sc
+}
+
 // ScalarFromBig converts a big.Int into a Scalar value in the correct range.
 // If the value of the Int is outside the order of the subgroup, ScalarFromBig
 // additionally returns an error indicating this was the case.
@@ -47,12 +64,12 @@ func (curve *Jubjub) ScalarFromBig(n *big.Int) (*Scalar, error) {
 	return newScalar(n, curve.subgroupOrder)
 }
 
-// FromBytes sets the scalar to the value of a little-endian bytestring and returns the scalar.
-// If the value would be outside the order of the subgroup, FromBytes reduces it.
-// FromBytes additionally returns an error if the value needed to be reduced.
+// fromBytes sets the scalar to the value of a little-endian bytestring of any
+// length and returns the scalar. If the value is not less than the order of the
+// subgroup, fromBytes reduces it and additionally returns ErrScalarOutOfRange.
 // It is unexported because it requires knowledge of the represented field and should be used from a concrete parameter set.
 func (sc *Scalar) fromBytes(in []byte) (*Scalar, error) {
-	words := make([]big.Word, (sc.fieldOrder.BitLen()+7)/bits.UintSize)
+	words := make([]big.Word, (len(in)*8+bits.UintSize-1)/bits.UintSize)
 	for n := range words {
 		for i := 0; i < bits.UintSize; i += 8 {
 			if len(in) == 0 {
@@ -65,7 +82,7 @@ func (sc *Scalar) fromBytes(in []byte) (*Scalar, error) {
 
 	sc.n.SetBits(words)
 
-	if sc.n.Cmp(sc.fieldOrder) == 1 || sc.n.Cmp(big.NewInt(0)) == -1 {
+	if sc.n.Cmp(sc.fieldOrder) != -1 || sc.n.Cmp(big.NewInt(0)) == -1 {
 		sc.n.Mod(sc.n, sc.fieldOrder)
 		return sc, ErrScalarOutOfRange
 	}
@@ -73,6 +90,23 @@ func (sc *Scalar) fromBytes(in []byte) (*Scalar, error) {
 	return sc, nil
 }
 
+// Equals compares two scalars and returns true if they are equal.
+func (sc *Scalar) Equals(x *Scalar) bool {
+	return sc.n.Cmp(x.n) == 0
+}
+
+// Add sets sc to the sum x+y, reducing the result by the subgroup order, and returns sc.
+func (sc *Scalar) Add(x, y *Scalar) *Scalar {
+	sc.n.Add(x.n, y.n).Mod(sc.n, sc.fieldOrder)
+	return sc
+}
+
+// Mul sets sc to the product x*y, reducing the result by the subgroup order, and returns sc.
+func (sc *Scalar) Mul(x, y *Scalar) *Scalar {
+	sc.n.Mul(x.n, y.n).Mod(sc.n, sc.fieldOrder)
+	return sc
+}
+
 // ToBytes reduces then converts the scalar to a little-endian bytestring.
 func (sc Scalar) ToBytes() []byte {
 	sc.n.Mod(sc.n, sc.fieldOrder)
@@ -0,0 +1,76 @@
+package jubjub
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestScalarFromBytes(t *testing.T) {
+	curve := Curve()
+	order := curve.subgroupOrder
+
+	le := func(n *big.Int, size int) []byte {
+		be := n.Bytes()
+		out := make([]byte, size)
+		for i := range be {
+			out[i] = be[len(be)-1-i]
+		}
+		return out
+	}
+
+	// The order itself is out of range and reduces to zero.
+	sc, err := curve.ScalarFromBytes(le(order, 32))
+	if err != ErrScalarOutOfRange {
+		t.Errorf("expected ErrScalarOutOfRange for r, got %v", err)
+	}
+	if sc.n.Sign() != 0 {
+		t.Errorf("r did not reduce to zero: %v", sc.n)
+	}
+
+	// r - 1 is the largest canonical value.
+	max := new(big.Int).Sub(order, big.NewInt(1))
+	sc, err = curve.ScalarFromBytes(le(max, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sc.ToBytes(), le(max, 32)) {
+		t.Error("r - 1 did not round-trip")
+	}
+
+	// Only canonical-length input is accepted.
+	for _, size := range []int{31, 33, 64} {
+		if sc, err := curve.ScalarFromBytes(make([]byte, size)); err != ErrScalarOutOfRange || sc != nil {
+			t.Errorf("accepted %d-byte input", size)
+		}
+	}
+
+	// ScalarFromBig agrees on the boundary.
+	if _, err := curve.ScalarFromBig(new(big.Int).Set(order)); err != ErrScalarOutOfRange {
+		t.Error("ScalarFromBig accepted r")
+	}
+	if _, err := curve.ScalarFromBig(max); err != nil {
+		t.Error("ScalarFromBig rejected r - 1")
+	}
+}
+
+func TestScalarFromUniformBytes(t *testing.T) {
+	curve := Curve()
+	order := curve.subgroupOrder
+
+	// Wide inputs are reduced, not truncated.
+	wide := new(big.Int).Lsh(big.NewInt(1), 511)
+	wide.Add(wide, big.NewInt(5))
+	in := make([]byte, 64)
+	for i, b := range wide.Bytes() {
+		in[63-i] = b
+	}
+	if sc := curve.ScalarFromUniformBytes(in); sc.n.Cmp(new(big.Int).Mod(wide, order)) != 0 {
+		t.Errorf("wide input: got %v", sc.n)
+	}
+
+	// Short input is zero-extended.
+	if sc := curve.ScalarFromUniformBytes([]byte{1, 2}); sc.n.Int64() != 0x0201 {
+		t.Errorf("short input: got %v", sc.n)
+	}
+}
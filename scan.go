@@ -0,0 +1,175 @@
+package jubjub
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// CompactOutput is the subset of a Sapling output that light clients receive
+// in compact blocks: the ephemeral key, the note commitment, and the prefix of
+// encCiphertext that covers the note plaintext without its memo.
+type CompactOutput struct {
+	Epk        [32]byte
+	Cmu        [32]byte
+	Ciphertext [CompactNotePlaintextSize]byte
+}
+
+// ScanResult is a note found by a BatchScanner.
+type ScanResult struct {
+	// OutputIndex is the index of the output in the scanned slice.
+	OutputIndex int
+
+	// IvkIndex is the index of the incoming viewing key that decrypted it.
+	IvkIndex int
+
+	Note *Note
+}
+
+// BatchScanner trial-decrypts compact outputs against a set of incoming viewing keys in parallel.
+type BatchScanner struct {
+	ivks    []*IncomingViewingKey
+	workers int
+}
+
+// NewBatchScanner returns a scanner for ivks that uses the given number of
+// goroutines. If workers is not positive, it uses runtime.GOMAXPROCS(0).
+func NewBatchScanner(ivks []*IncomingViewingKey, workers int) *BatchScanner {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return &BatchScanner{ivks, workers}
+}
+
+// scanBatchSize is the number of outputs whose epks a worker decompresses
+// together.
+const scanBatchSize = 64
+
+// Scan attempts to decrypt every output with every key and returns the matches
+// ordered by output index. Workers take outputs in batches of scanBatchSize
+// and decompress the batch's epks with DecompressBatch, which shares one field
+// inversion between them. Each epk is then checked for small order once and
+// shared by all of the keys; outputs with an invalid epk are skipped.
+func (s *BatchScanner) Scan(outputs []CompactOutput) []ScanResult {
+	jobs := make(chan int)
+	found := make(chan ScanResult)
+
+	var wg sync.WaitGroup
+	for w := 0; w < s.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range jobs {
+				end := start + scanBatchSize
+				if end > len(outputs) {
+					end = len(outputs)
+				}
+				s.scanBatch(start, outputs[start:end], found)
+			}
+		}()
+	}
+
+	go func() {
+		for start := 0; start < len(outputs); start += scanBatchSize {
+			jobs <- start
+		}
+		close(jobs)
+		wg.Wait()
+		close(found)
+	}()
+
+	var results []ScanResult
+	for r := range found {
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].OutputIndex != results[j].OutputIndex {
+			return results[i].OutputIndex < results[j].OutputIndex
+		}
+		return results[i].IvkIndex < results[j].IvkIndex
+	})
+
+	return results
+}
+
+// scanBatch tries every key against a batch of outputs, the first of which is
+// at index start, and sends any matches to found.
+func (s *BatchScanner) scanBatch(start int, outputs []CompactOutput, found chan<- ScanResult) {
+	if len(s.ivks) == 0 {
+		return
+	}
+
+	encs := make([][]byte, len(outputs))
+	for i := range outputs {
+		encs[i] = outputs[i].Epk[:]
+	}
+	epks := s.ivks[0].curve.DecompressBatch(encs)
+
+	for i, epk := range epks {
+		if epk == nil || kaValidatePublic(epk) != nil {
+			continue
+		}
+		for j, ivk := range s.ivks {
+			note, err := tryDecryptCompactNote(ivk, epk, outputs[i].Cmu[:], outputs[i].Ciphertext[:])
+			if err != nil {
+				continue
+			}
+			found <- ScanResult{start + i, j, note}
+		}
+	}
+}
+
+// TryDecryptCompactNote attempts to decrypt the compact prefix of an output's
+// encCiphertext. Without the authentication tag the ciphertext can't be
+// authenticated, but the recovered note is still checked against cmu.
+func TryDecryptCompactNote(ivk *IncomingViewingKey, epk *Point, cmu []byte, ciphertext []byte) (*Note, error) {
+	if err := kaValidatePublic(epk); err != nil {
+		return nil, err
+	}
+	return tryDecryptCompactNote(ivk, epk, cmu, ciphertext)
+}
+
+// tryDecryptCompactNote is TryDecryptCompactNote for an epk that has already
+// passed kaValidatePublic.
+func tryDecryptCompactNote(ivk *IncomingViewingKey, epk *Point, cmu []byte, ciphertext []byte) (*Note, error) {
+	if len(ciphertext) != CompactNotePlaintextSize {
+		return nil, ErrNoteDecryptionFailed
+	}
+
+	sharedSecret, err := ivk.curve.kaAgree(ivk.ivk, epk)
+	if err != nil {
+		return nil, err
+	}
+
+	// ChaCha20-Poly1305 encrypts the plaintext starting at block 1 of the
+	// keystream, since block 0 is used for the Poly1305 key.
+	var nonce [chacha20.NonceSize]byte
+	stream, err := chacha20.NewUnauthenticatedCipher(kdfSapling(sharedSecret, epk), nonce[:])
+	if err != nil {
+		return nil, err
+	}
+	stream.SetCounter(1)
+
+	plaintext := make([]byte, CompactNotePlaintextSize)
+	stream.XORKeyStream(plaintext, ciphertext)
+
+	np, err := parseNotePlaintext(plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := ivk.Address(np.d)
+	if err != nil {
+		return nil, ErrInvalidNotePlaintext
+	}
+
+	note := np.note(addr)
+	if err := note.checkOutput(epk, cmu); err != nil {
+		return nil, err
+	}
+
+	return note, nil
+}
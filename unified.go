@@ -0,0 +1,290 @@
+package jubjub
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidUnifiedEncoding = errors.New("not a valid unified encoding")
+)
+
+// Typecodes of unified address and viewing key items from ZIP 316.
+const (
+	UnifiedP2PKH   uint32 = 0x00
+	UnifiedP2SH    uint32 = 0x01
+	UnifiedSapling uint32 = 0x02
+	UnifiedOrchard uint32 = 0x03
+)
+
+// Typecodes 0xC0 through 0xFC are metadata rather than receivers or keys. Of
+// those, 0xE0 and above must be understood by a parser, and none are defined
+// for revision 0 encodings.
+const (
+	unifiedMetadataMin       = 0xC0
+	unifiedMustUnderstandMin = 0xE0
+	unifiedMetadataMax       = 0xFC
+)
+
+// Human-readable parts for unified encodings, indexed by Network.
+var (
+	unifiedAddressHRP          = [...]string{"u", "utest"}
+	unifiedFullViewingKeyHRP   = [...]string{"uview", "uviewtest"}
+	unifiedAddressItemSizes    = map[uint32]int{UnifiedP2PKH: 20, UnifiedP2SH: 20, UnifiedSapling: PaymentAddressSize, UnifiedOrchard: 43}
+	unifiedViewingKeyItemSizes = map[uint32]int{UnifiedP2PKH: 65, UnifiedSapling: DiversifiableFullViewingKeySize, UnifiedOrchard: 96}
+)
+
+// unifiedMaxLength is the length of the longest unified encoding, whose
+// F4Jumble input is as long as F4Jumble allows.
+const unifiedMaxLength = len("uviewtest") + 1 + (f4JumbleMaxLen*8+4)/5 + 6
+
+// UnifiedItem is an item of a unified address or viewing key that this package
+// doesn't interpret, such as a transparent or Orchard receiver.
+type UnifiedItem struct {
+	Typecode uint32
+	Data     []byte
+}
+
+// UnifiedAddress is a ZIP 316 unified address.
+type UnifiedAddress struct {
+	Sapling *PaymentAddress
+	Other   []UnifiedItem
+}
+
+// Encode returns the Bech32m encoding of the address, such as "u1...".
+func (ua *UnifiedAddress) Encode(net Network) (string, error) {
+	items := ua.Other
+	if ua.Sapling != nil {
+		data, err := ua.Sapling.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		items = append([]UnifiedItem{{UnifiedSapling, data}}, items...)
+	}
+	hrp, err := net.hrp(unifiedAddressHRP)
+	if err != nil {
+		return "", err
+	}
+	return encodeUnified(hrp, items, unifiedAddressItemSizes)
+}
+
+// DecodeUnifiedAddress parses a unified address and reports which network it is
+// for. A Sapling receiver is validated as by PaymentAddressFromBytes.
+func (curve *Jubjub) DecodeUnifiedAddress(s string) (*UnifiedAddress, Network, error) {
+	net, items, err := decodeUnified(s, unifiedAddressHRP, unifiedAddressItemSizes)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ua := &UnifiedAddress{}
+	for _, item := range items {
+		if item.Typecode != UnifiedSapling {
+			ua.Other = append(ua.Other, item)
+			continue
+		}
+		if ua.Sapling, err = curve.PaymentAddressFromBytes(item.Data); err != nil {
+			return nil, 0, err
+		}
+	}
+	return ua, net, nil
+}
+
+// DiversifiableFullViewingKeySize is the length of an encoded DiversifiableFullViewingKey.
+const DiversifiableFullViewingKeySize = 4 * 32
+
+// DiversifiableFullViewingKey is a Sapling full viewing key together with the
+// diversifier key needed to derive its addresses. It is the Sapling item of a
+// unified full viewing key.
+type DiversifiableFullViewingKey struct {
+	Fvk FullViewingKey
+	Dk  DiversifierKey
+}
+
+// DiversifiableFullViewingKey returns the key without its ZIP 32 derivation path.
+func (xfvk *ExtendedFullViewingKey) DiversifiableFullViewingKey() *DiversifiableFullViewingKey {
+	return &DiversifiableFullViewingKey{xfvk.Fvk, xfvk.Dk}
+}
+
+// MarshalBinary encodes the key as repr(ak) || repr(nk) || ovk || dk.
+func (dfvk *DiversifiableFullViewingKey) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, DiversifiableFullViewingKeySize)
+	out = append(out, dfvk.Fvk.Ak.Compress()...)
+	out = append(out, dfvk.Fvk.Nk.Compress()...)
+	out = append(out, dfvk.Fvk.Ovk[:]...)
+	return append(out, dfvk.Dk[:]...), nil
+}
+
+// DiversifiableFullViewingKeyFromBytes decodes a 128-byte key. Both ak and nk
+// must be in the prime-order subgroup, and ak must not be the identity.
+func (curve *Jubjub) DiversifiableFullViewingKeyFromBytes(in []byte) (*DiversifiableFullViewingKey, error) {
+	if len(in) != DiversifiableFullViewingKeySize {
+		return nil, ErrInvalidUnifiedEncoding
+	}
+
+	fvk, err := curve.fullViewingKeyFromBytes(in[:96])
+	if err != nil {
+		return nil, err
+	}
+
+	dfvk := &DiversifiableFullViewingKey{Fvk: *fvk}
+	copy(dfvk.Dk[:], in[96:])
+	return dfvk, nil
+}
+
+// UnifiedFullViewingKey is a ZIP 316 unified full viewing key.
+type UnifiedFullViewingKey struct {
+	Sapling *DiversifiableFullViewingKey
+	Other   []UnifiedItem
+}
+
+// Encode returns the Bech32m encoding of the key, such as "uview1...".
+func (ufvk *UnifiedFullViewingKey) Encode(net Network) (string, error) {
+	items := ufvk.Other
+	if ufvk.Sapling != nil {
+		data, err := ufvk.Sapling.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		items = append([]UnifiedItem{{UnifiedSapling, data}}, items...)
+	}
+	hrp, err := net.hrp(unifiedFullViewingKeyHRP)
+	if err != nil {
+		return "", err
+	}
+	return encodeUnified(hrp, items, unifiedViewingKeyItemSizes)
+}
+
+// DecodeUnifiedFullViewingKey parses a unified full viewing key and reports
+// which network it is for. A Sapling key is validated as by
+// DiversifiableFullViewingKeyFromBytes.
+func (curve *Jubjub) DecodeUnifiedFullViewingKey(s string) (*UnifiedFullViewingKey, Network, error) {
+	net, items, err := decodeUnified(s, unifiedFullViewingKeyHRP, unifiedViewingKeyItemSizes)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ufvk := &UnifiedFullViewingKey{}
+	for _, item := range items {
+		if item.Typecode != UnifiedSapling {
+			ufvk.Other = append(ufvk.Other, item)
+			continue
+		}
+		if ufvk.Sapling, err = curve.DiversifiableFullViewingKeyFromBytes(item.Data); err != nil {
+			return nil, 0, err
+		}
+	}
+	return ufvk, net, nil
+}
+
+// unifiedPadding is the 16-byte suffix hrp || [0]^(16-len(hrp)).
+func unifiedPadding(hrp string) []byte {
+	pad := make([]byte, 16)
+	copy(pad, hrp)
+	return pad
+}
+
+// checkUnifiedItems enforces the ZIP 316 rules on a sorted list of items.
+// sizes gives the required length of each known typecode; a typecode absent
+// from sizes is not allowed in this kind of container. At least one item must
+// be shielded: Sapling, Orchard, or an unknown typecode, which ZIP 316
+// presumes to be shielded.
+func checkUnifiedItems(items []UnifiedItem, sizes map[uint32]int) error {
+	hasShielded := false
+	for i, item := range items {
+		if i > 0 && item.Typecode <= items[i-1].Typecode {
+			return ErrInvalidUnifiedEncoding
+		}
+
+		switch {
+		case item.Typecode >= unifiedMustUnderstandMin && item.Typecode <= unifiedMetadataMax:
+			return ErrInvalidUnifiedEncoding
+		case item.Typecode >= unifiedMetadataMin && item.Typecode <= unifiedMetadataMax:
+			continue
+		}
+		if item.Typecode >= UnifiedSapling {
+			hasShielded = true
+		}
+
+		if item.Typecode <= UnifiedOrchard {
+			if size, ok := sizes[item.Typecode]; !ok || len(item.Data) != size {
+				return ErrInvalidUnifiedEncoding
+			}
+		}
+		if item.Typecode == UnifiedP2SH && i > 0 && items[i-1].Typecode == UnifiedP2PKH {
+			return ErrInvalidUnifiedEncoding
+		}
+	}
+
+	if !hasShielded {
+		return ErrInvalidUnifiedEncoding
+	}
+	return nil
+}
+
+// encodeUnified sorts items by typecode and encodes them as
+// Bech32m(hrp, F4Jumble(TLV items || padding)).
+func encodeUnified(hrp string, items []UnifiedItem, sizes map[uint32]int) (string, error) {
+	sorted := append([]UnifiedItem{}, items...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Typecode < sorted[j].Typecode })
+	if err := checkUnifiedItems(sorted, sizes); err != nil {
+		return "", err
+	}
+
+	var raw []byte
+	for _, item := range sorted {
+		raw = appendCompactSize(raw, uint64(item.Typecode))
+		raw = appendCompactSize(raw, uint64(len(item.Data)))
+		raw = append(raw, item.Data...)
+	}
+	raw = append(raw, unifiedPadding(hrp)...)
+
+	jumbled, err := f4Jumble(raw)
+	if err != nil {
+		return "", err
+	}
+	return bech32Encode(hrp, jumbled, bech32m), nil
+}
+
+// decodeUnified parses a unified encoding whose human-readable part is in
+// table and returns its items in order.
+func decodeUnified(s string, table [2]string, sizes map[uint32]int) (Network, []UnifiedItem, error) {
+	hrp, data, err := bech32Decode(s, bech32m, unifiedMaxLength)
+	if err != nil {
+		return 0, nil, err
+	}
+	net, err := lookupHRP(table, hrp)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	raw, err := f4JumbleInv(data)
+	if err != nil {
+		return 0, nil, ErrInvalidUnifiedEncoding
+	}
+	if !bytes.Equal(raw[len(raw)-16:], unifiedPadding(hrp)) {
+		return 0, nil, ErrInvalidUnifiedEncoding
+	}
+	raw = raw[:len(raw)-16]
+
+	var items []UnifiedItem
+	for len(raw) > 0 {
+		typecode, rest, err := readCompactSize(raw)
+		if err != nil {
+			return 0, nil, ErrInvalidUnifiedEncoding
+		}
+		length, rest, err := readCompactSize(rest)
+		if err != nil || length > uint64(len(rest)) {
+			return 0, nil, ErrInvalidUnifiedEncoding
+		}
+
+		items = append(items, UnifiedItem{uint32(typecode), rest[:length]})
+		raw = rest[length:]
+	}
+
+	if err := checkUnifiedItems(items, sizes); err != nil {
+		return 0, nil, err
+	}
+	return net, items, nil
+}
@@ -0,0 +1,267 @@
+package jubjub
+
+import (
+	"bytes"
+	"testing"
+)
+
+// From https://github.com/zcash/zcash-test-vectors/blob/master/zcash_test_vectors/f4jumble.py
+var f4JumbleVectors = []struct {
+	normal, jumbled string
+}{
+	{
+		normal:  "5d7a8f739a2d9e945b0ce152a8049e294c4d6e66b164939daffa2ef6ee6921481cdd86b3cc4318d9614fc820905d042b",
+		jumbled: "0304d029141b995da5387c125970673504d6c764d91ea6c082123770c7139ccd88ee27368cd0c0921a0444c8e5858d22",
+	},
+	{
+		normal:  "b1ef9ca3f24988c7b3534201cfb1cd8dbf69b8250c18ef41294ca97993db546c1fe01f7e9c8e36d6a5e29d4e30a73594bf5098421c69378af1e40f64e125946f",
+		jumbled: "5271fa3321f3adbcfb075196883d542b438ec6339176537daf859841fe6a56222bff76d1662b5509a9e1079e446eeedd2e683c31aae3ee1851d7954328526be1",
+	},
+	{
+		normal:  "62c2fa7b2fecbcb64b6968912a6381ce3dc166d56a1d62f5a8d7551db5fd9313e8c7203d996af7d477083756d59af80d06a745f44ab023752cb5b406ed8985e18130ab33362697b0e4e4c763ccb8f676495c222f7fba1e31defa3d5a57efc2e1e9b01a035587d5fb1a38e01d94903d3c3e0ad3360c1d3710acd20b183e31d49f",
+		jumbled: "498cf1b1ba6f4577effe64151d67469adc30acc325e326207e7d78487085b4162669f82f02f9774c0cc26ae6e1a76f1e266c6a9a8a2f4ffe8d2d676b1ed71cc47195a3f19208998f7d8cdfc0b74d2a96364d733a62b4273c77d9828aa1fa061588a7c4c88dd3d3dde02239557acfaad35c55854f4541e1a1b3bc8c17076e7316",
+	},
+	{
+		normal:  "25c9a138f49b1a537edcf04be34a9851a7af9db6990ed83dd64af3597c04323ea51b0052ad8084a8b9da948d320dadd64f5431e61ddf658d24ae67c22c8d1309131fc00fe7f235734276d38d47f1e191e00c7a1d48af046827591e9733a97fa6b679f3dc601d008285edcbdae69ce8fc1be4aac00ff2711ebd931de518856878f7",
+		jumbled: "7508a3a146714f229db91b543e240633ed57853f6451c9db6d64c6e86af1b88b28704f608582c53c51ce7d5b8548827a971d2b98d41b7f6258655902440cd66ee11e84dbfac7d2a43696fd0468810a3d9637c3fa58e7d2d341ef250fa09b9fb71a78a41d389370138a55ea58fcde779d714a04e0d30e61dc2d8be0da61cd684509",
+	},
+}
+
+func TestF4Jumble(t *testing.T) {
+	for i, tv := range f4JumbleVectors {
+		normal, jumbled := decodeHex(t, tv.normal), decodeHex(t, tv.jumbled)
+
+		out, err := f4Jumble(normal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, jumbled) {
+			t.Errorf("Incorrect jumble for test %d:\nWant: %x\nHave: %x", i, jumbled, out)
+		}
+
+		out, err = f4JumbleInv(jumbled)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, normal) {
+			t.Errorf("Incorrect inverse for test %d:\nWant: %x\nHave: %x", i, normal, out)
+		}
+	}
+
+	if _, err := f4Jumble(make([]byte, f4JumbleMinLen-1)); err != ErrInvalidJumbleLength {
+		t.Error("Jumbled a message that was too short")
+	}
+}
+
+// From https://github.com/zcash/zcash-test-vectors/blob/master/zcash_test_vectors/unified_address.py
+var unifiedAddressVectors = []struct {
+	sapling string
+	others  int
+	encoded string
+}{
+	{
+		sapling: "d8ef8293d26de832e7193f296ba1922d90f122c6135bc231eebd91efdb03b1a8606771cd4fd6480574d43e",
+		others:  1,
+		encoded: "u1l8xunezsvhq8fgzfl7404m450nwnd76zshscn6nfys7vyz2ywyh4cc5daaq0c7q2su5lqfh23sp7fkf3kt27ve5948mzpfdvckzaect2jtte308mkwlycj2u0eac077wu70vqcetkxf",
+	},
+	{
+		sapling: "9f6e0bf90a18fc0b9b83ae9f23ad4358648638482b5def8975635b66fd8a708335f9235a3186ec0f033f84",
+		others:  2,
+		encoded: "u1pg2aaph7jp8rpf6yhsza25722sg5fcn3vaca6ze27hqjw7jvvhhuxkpcg0ge9xh6drsgdkda8qjq5chpehkcpxf87rnjryjqwymdheptpvnljqqrjqzjwkc2ma6hcq666kgwfytxwac8eyex6ndgr6ezte66706e3vaqrd25dzvzkc69kw0jgywtd0cmq52q5lkw6uh7hyvzjse8ksx",
+	},
+	{
+		sapling: "da2672c010f7364df6fad49dd39be0e4d4be73c45e239448fcc385cc68094bf36ddbc4ec0219b567955556",
+		others:  1,
+		encoded: "u1sem2gcey0emntrvxyjv8hyhq0w5fr4sxaj3cppgrfqgg6laydh8m78gy2cw2p54zzak3alnnsx4xjuhazpkrfcd90wl0c7ldj6y095hh5j6j2evry9vg5jqp4dyqpwqeryu7pes4sxyyyqwn6egs5daxk4473v9xpgzrwv5n0tvs93nlj4xpphq4vs2w8um9ph7zkte08t7fa509mnrt9apuhr22xq34mp2svjnq6rvfn0hg6lkehxtlj39vgjxjlkjfhx8rw2f02ckq8k5szcxsnhkgr2cqlmf2udl2gqdqr5t6",
+	},
+	{
+		others:  1,
+		encoded: "u1ddnjsdcpm36r6aq79n3s68shjweksnmwtdltrh046s8m6xcws9ygyawalxx8n6hg6vegk0wh8zjnafxgh6msppjsljvyt0ynece3lvm0",
+	},
+}
+
+func TestUnifiedAddress(t *testing.T) {
+	curve := Curve()
+	for i, tv := range unifiedAddressVectors {
+		ua, net, err := curve.DecodeUnifiedAddress(tv.encoded)
+		if err != nil {
+			t.Fatalf("Couldn't decode test %d: %v", i, err)
+		}
+		if net != Mainnet {
+			t.Errorf("Incorrect network for test %d", i)
+		}
+
+		if tv.sapling == "" {
+			if ua.Sapling != nil {
+				t.Errorf("Found a Sapling receiver in test %d", i)
+			}
+		} else {
+			if ua.Sapling == nil {
+				t.Fatalf("Missing Sapling receiver in test %d", i)
+			}
+			if enc, _ := ua.Sapling.MarshalBinary(); !bytes.Equal(enc, decodeHex(t, tv.sapling)) {
+				t.Errorf("Incorrect Sapling receiver for test %d:\nWant: %s\nHave: %x", i, tv.sapling, enc)
+			}
+		}
+		if len(ua.Other) != tv.others {
+			t.Errorf("Expected %d other receivers in test %d, found %d", tv.others, i, len(ua.Other))
+		}
+
+		if enc, err := ua.Encode(Mainnet); err != nil || enc != tv.encoded {
+			t.Errorf("Test %d didn't roundtrip: %v", i, err)
+		}
+	}
+}
+
+func TestUnifiedAddressValidation(t *testing.T) {
+	curve := Curve()
+	tv := saplingKeyComponents[0]
+	sapling := append(decodeHex(t, tv.defaultD), decodeHex(t, tv.defaultPkD)...)
+	p2pkh := UnifiedItem{UnifiedP2PKH, make([]byte, 20)}
+
+	// (0, -1) has order 2.
+	small := curve.Identity()
+	small.y.Neg(small.y)
+	badSapling := append(decodeHex(t, tv.defaultD), small.Compress()...)
+
+	// encodeUnified accepts a bad pk_d, since it doesn't interpret receivers.
+	enc, err := encodeUnified("u", []UnifiedItem{{UnifiedSapling, badSapling}}, unifiedAddressItemSizes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := curve.DecodeUnifiedAddress(enc); err != ErrInvalidAddress {
+		t.Error("Accepted a Sapling receiver with a small-order pk_d")
+	}
+
+	invalid := map[string][]UnifiedItem{
+		"no receivers":       {},
+		"only metadata":      {{0xC0, []byte{1}}},
+		"only p2pkh":         {p2pkh},
+		"must-understand":    {{UnifiedSapling, sapling}, {0xE0, []byte{1}}},
+		"duplicate typecode": {p2pkh, p2pkh},
+		"p2pkh and p2sh":     {p2pkh, {UnifiedP2SH, make([]byte, 20)}},
+		"short receiver":     {{UnifiedSapling, sapling[1:]}},
+	}
+	for name, items := range invalid {
+		if _, err := encodeUnified("u", items, unifiedAddressItemSizes); err != ErrInvalidUnifiedEncoding {
+			t.Errorf("Encoded an address with %s", name)
+		}
+	}
+
+	// A transparent-only address can't be decoded either. A metadata item
+	// makes it long enough for F4Jumble.
+	raw := append([]byte{byte(UnifiedP2PKH), 20}, p2pkh.Data...)
+	raw = append(raw, append([]byte{0xC0, 10}, make([]byte, 10)...)...)
+	jumbled, err := f4Jumble(append(raw, unifiedPadding("u")...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := curve.DecodeUnifiedAddress(bech32Encode("u", jumbled, bech32m)); err != ErrInvalidUnifiedEncoding {
+		t.Errorf("Decoded an address with only a P2PKH receiver: %v", err)
+	}
+
+	// Items are sorted on encoding, so receivers can be given in any order.
+	addr, _ := curve.PaymentAddressFromBytes(sapling)
+	ua := &UnifiedAddress{Sapling: addr, Other: []UnifiedItem{{0x05, []byte{1, 2, 3}}, p2pkh}}
+	enc, err = ua.Encode(Testnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, net, err := curve.DecodeUnifiedAddress(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net != Testnet || len(decoded.Other) != 2 || decoded.Other[0].Typecode != UnifiedP2PKH || !decoded.Sapling.PkD.Equals(addr.PkD) {
+		t.Error("Incorrect roundtrip of a testnet address")
+	}
+
+	// A unified address isn't a Bech32 Sapling address, nor the reverse.
+	if _, _, err := curve.DecodePaymentAddress(enc); err == nil {
+		t.Error("Decoded a unified address as a Sapling address")
+	}
+	saplingEnc, err := addr.Encode(Testnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := curve.DecodeUnifiedAddress(saplingEnc); err == nil {
+		t.Error("Decoded a Sapling address as a unified address")
+	}
+
+	if _, err := ua.Encode(Network(-1)); err != ErrUnknownNetwork {
+		t.Error("Encoded a unified address for an unknown network")
+	}
+}
+
+// From https://github.com/zcash/zcash-test-vectors/blob/master/zcash_test_vectors/unified_full_viewing_keys.py
+var unifiedFullViewingKeyVectors = []struct {
+	sapling string
+	encoded string
+}{
+	{
+		sapling: "0fec4a4553bde5638ff97ac262635ddce4fd10e9b9eedb3bf2b60a4cb01730e1971db5d0921a68a3e4d78379076f47fb26388a874a16c14dd21cacfc0f14475e4381ed27a5002ea7a9c87339d66ba14675e7f806d9580b2e71c4d166c15243a57b2ce29b1c441fb68534de0441e5d75c2da24667372d9ae6db6cf63693f44d86",
+		encoded: "uview18jpf4cjyt5nfa747ua4sawtv9cppl8g576a4utmtslvenzm2ajyefz2fye2w7ljjm63f6r903fuhdm6fmvg3dnpgxw07tllfq7hcede8qyl2fanaarvsm8d0trz5ckc7k47dne78mfw5lrkgc883akkaw2vt37cdmvy6snapxufr857r5p9vmf9jx3s00w73we6fz4w49yy9wdc3u92krx0vs05t3c4r6favdtg9uj2tqs94skm5xdn9q4vpwgfmkcgwl3c8sj4epph8f69839q8p7pt86xvd5ejs7k87dn3tlwfenzqzlhalm7vwwaqy56mmdysdnqemmses6s653n6xq4",
+	},
+	{
+		sapling: "82c3b3d788f6cc8714421d6b74c20b5bc58debd9a33e24b4be99a4c601959b34d8436534693bb2f793ad6e4b488d9c7e202771f11cae68405ec816c71b4a906401d4cb12115fbd978735bf537d8589154a8a84ca61d6c202b60477107a5e0cf8de349c22cd8bfab48e92e90834504d65a438ab76010e3bab7437153a79755d17",
+		encoded: "uview1krnvjn9nk9ysyzazqctrwct7xpkp75hr09zu8laz8ae5kuj9tgujr5ufm2fadxmyr9cl2ycsmeednh4jdeyt7ttzq7c7rjhqn7w3wq50l2xec85stczj2wvp7cu6uc2du6ye00q00fg90vnfwrwyuwfctnfzwk6zk489q0avc4juehte32lktanszeu7h8zus0xp3cg6sk8nstahex05watuw05e483qyhnf6r2dxv5qyte3",
+	},
+}
+
+func TestUnifiedFullViewingKey(t *testing.T) {
+	curve := Curve()
+	for i, tv := range unifiedFullViewingKeyVectors {
+		ufvk, net, err := curve.DecodeUnifiedFullViewingKey(tv.encoded)
+		if err != nil {
+			t.Fatalf("Couldn't decode test %d: %v", i, err)
+		}
+		if net != Mainnet || ufvk.Sapling == nil {
+			t.Fatalf("Incorrect decoding for test %d", i)
+		}
+		if enc, _ := ufvk.Sapling.MarshalBinary(); !bytes.Equal(enc, decodeHex(t, tv.sapling)) {
+			t.Errorf("Incorrect Sapling key for test %d:\nWant: %s\nHave: %x", i, tv.sapling, enc)
+		}
+		if enc, err := ufvk.Encode(Mainnet); err != nil || enc != tv.encoded {
+			t.Errorf("Test %d didn't roundtrip: %v", i, err)
+		}
+	}
+
+	// An identity ak must be rejected.
+	bad := decodeHex(t, unifiedFullViewingKeyVectors[0].sapling)
+	copy(bad, curve.Identity().Compress())
+	enc, err := encodeUnified("uview", []UnifiedItem{{UnifiedSapling, bad}}, unifiedViewingKeyItemSizes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := curve.DecodeUnifiedFullViewingKey(enc); err != ErrInvalidPoint {
+		t.Error("Accepted a Sapling key with an identity ak")
+	}
+
+	// P2SH items aren't allowed in viewing keys.
+	p2sh := UnifiedItem{UnifiedP2SH, make([]byte, 20)}
+	if _, err := (&UnifiedFullViewingKey{Other: []UnifiedItem{p2sh}}).Encode(Mainnet); err != ErrInvalidUnifiedEncoding {
+		t.Error("Encoded a viewing key with a P2SH item")
+	}
+}
+
+func TestCompactSize(t *testing.T) {
+	for _, n := range []uint64{0, 0xfc, 0xfd, 0xffff, 0x10000, maxCompactSize} {
+		enc := appendCompactSize(nil, n)
+		have, rest, err := readCompactSize(append(enc, 0xaa))
+		if err != nil || have != n || !bytes.Equal(rest, []byte{0xaa}) {
+			t.Errorf("%d didn't roundtrip: %v", n, err)
+		}
+	}
+
+	invalid := [][]byte{
+		{},
+		{0xfd, 0xfc, 0x00},             // non-canonical
+		{0xfe, 0xff, 0xff, 0x00, 0x00}, // non-canonical
+		{0xfe, 0x01, 0x00, 0x00, 0x02}, // larger than maxCompactSize
+		{0xfd, 0x00},                   // truncated
+	}
+	for _, in := range invalid {
+		if _, _, err := readCompactSize(in); err != ErrInvalidCompactSize {
+			t.Errorf("Accepted %x", in)
+		}
+	}
+}
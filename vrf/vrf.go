@@ -0,0 +1,204 @@
+// Package vrf implements a verifiable random function on Jubjub following
+// ECVRF from RFC 9381. Proofs have the RFC's layout Gamma || c || s with a
+// 16-byte challenge, but hash the input to the curve with Elligator 2 for
+// Jubjub, so they aren't interoperable with any RFC 9381 suite.
+package vrf
+
+import (
+	"bytes"
+	"crypto/sha512"
+
+	"github.com/gtank/jubjub"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidProof = errors.New("not a valid VRF proof encoding")
+)
+
+const (
+	// challengeSize is cLen, the length of the challenge c in a proof.
+	challengeSize = 16
+
+	// ProofSize is the length of a proof pi, Gamma || c || s.
+	ProofSize = 32 + challengeSize + 32
+
+	// OutputSize is the length of the VRF output beta.
+	OutputSize = sha512.Size
+)
+
+// suiteString identifies this construction in every hash it makes.
+const suiteString = "JUBJUB-ECVRF-XMD:SHA-512_ELL2_NU_-V01"
+
+// h2cSuite is the RFC 9380 suite used for encode_to_curve.
+const h2cSuite = "jubjub_XMD:SHA-512_ELL2_NU_"
+
+var curve = jubjub.Curve()
+
+// PublicKey returns the public key Y = [sk] B, where B generates the
+// prime-order subgroup.
+func PublicKey(sk *jubjub.Scalar) *jubjub.Point {
+	Y, _ := curve.ScalarMult(sk, curve.SubgroupGenerator())
+	return Y
+}
+
+// hashToCurve is ECVRF_encode_to_curve with salt = PK_string, as in RFC 9381
+// section 5.4.1.2.
+func hashToCurve(pk *jubjub.Point, alpha []byte) (*jubjub.Point, error) {
+	msg := append(pk.Compress(), alpha...)
+	return curve.EncodeToCurve(sha512.New, msg, []byte("ECVRF_"+h2cSuite+suiteString))
+}
+
+// proofToHash is the output step of ECVRF_proof_to_hash from RFC 9381 section
+// 5.2, which clears the cofactor of Gamma before hashing it:
+//
+//	beta = SHA-512(suite_string || 0x03 || point_to_string([h] Gamma) || 0x00)
+func proofToHash(gamma *jubjub.Point) []byte {
+	h := sha512.New()
+	h.Write([]byte(suiteString))
+	h.Write([]byte{0x03})
+	h.Write(gamma.Clone().MulByCofactor().Compress())
+	h.Write([]byte{0x00})
+	return h.Sum(nil)
+}
+
+// nonceGeneration is ECVRF_nonce_generation from RFC 9381 section 5.4.2.2,
+// with the encoding of sk in place of the EdDSA secret key:
+//
+//	k = SHA-512(SHA-512(sk)[32:64] || h_string) mod q
+func nonceGeneration(sk *jubjub.Scalar, hString []byte) *jubjub.Scalar {
+	hashedSK := sha512.Sum512(sk.ToBytes())
+	h := sha512.New()
+	h.Write(hashedSK[32:])
+	h.Write(hString)
+	return curve.ScalarFromUniformBytes(h.Sum(nil))
+}
+
+// challengeGeneration is ECVRF_challenge_generation from RFC 9381 section
+// 5.4.3. It returns the challenge as its cLen-byte encoding.
+func challengeGeneration(points ...*jubjub.Point) []byte {
+	h := sha512.New()
+	h.Write([]byte(suiteString))
+	h.Write([]byte{0x02})
+	for _, p := range points {
+		h.Write(p.Compress())
+	}
+	h.Write([]byte{0x00})
+	return h.Sum(nil)[:challengeSize]
+}
+
+// Prove returns the proof pi that beta is the VRF output of alpha under the key
+// sk, following RFC 9381 section 5.1. Gamma = [sk] H for the hashed input H,
+// and (c, s) show that Gamma and the public key share a discrete log. The
+// nonce is derived deterministically from sk and H, so Prove needs no
+// randomness.
+func Prove(sk *jubjub.Scalar, alpha []byte) (pi, beta []byte, err error) {
+	B := curve.SubgroupGenerator()
+	Y, err := curve.ScalarMult(sk, B)
+	if err != nil {
+		return nil, nil, err
+	}
+	H, err := hashToCurve(Y, alpha)
+	if err != nil {
+		return nil, nil, err
+	}
+	gamma, err := curve.ScalarMult(sk, H)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k := nonceGeneration(sk, H.Compress())
+	kB, err := curve.ScalarMult(k, B)
+	if err != nil {
+		return nil, nil, err
+	}
+	kH, err := curve.ScalarMult(k, H)
+	if err != nil {
+		return nil, nil, err
+	}
+	cString := challengeGeneration(Y, H, gamma, kB, kH)
+
+	// s = k + c * sk
+	c := curve.ScalarFromUniformBytes(cString)
+	s := curve.ScalarFromUniformBytes(nil)
+	s.Mul(c, sk).Add(s, k)
+
+	pi = append(gamma.Compress(), cString...)
+	pi = append(pi, s.ToBytes()...)
+	return pi, proofToHash(gamma), nil
+}
+
+// Verify reports whether pi is a valid proof for alpha under the public key pk
+// and if so returns the VRF output beta, following RFC 9381 section 5.3. It
+// rejects public keys of small order, for which a proof could take many values
+// of Gamma.
+func Verify(pk *jubjub.Point, alpha, pi []byte) (beta []byte, ok bool) {
+	if pk.Clone().MulByCofactor().IsIdentity() {
+		return nil, false
+	}
+
+	gamma, cString, s, err := decodeProof(pi)
+	if err != nil {
+		return nil, false
+	}
+	H, err := hashToCurve(pk, alpha)
+	if err != nil {
+		return nil, false
+	}
+
+	// U = [s] B - [c] Y and V = [s] H - [c] Gamma
+	c := curve.ScalarFromUniformBytes(cString)
+	U, err := subMult(s, curve.SubgroupGenerator(), c, pk)
+	if err != nil {
+		return nil, false
+	}
+	V, err := subMult(s, H, c, gamma)
+	if err != nil {
+		return nil, false
+	}
+
+	if !bytes.Equal(challengeGeneration(pk, H, gamma, U, V), cString) {
+		return nil, false
+	}
+	return proofToHash(gamma), true
+}
+
+// subMult returns [a] P - [b] Q.
+func subMult(a *jubjub.Scalar, P *jubjub.Point, b *jubjub.Scalar, Q *jubjub.Point) (*jubjub.Point, error) {
+	aP, err := curve.ScalarMult(a, P)
+	if err != nil {
+		return nil, err
+	}
+	bQ, err := curve.ScalarMult(b, Q)
+	if err != nil {
+		return nil, err
+	}
+	return aP.Add(aP, bQ.Neg(bQ)), nil
+}
+
+// ProofToHash returns the VRF output beta for pi without verifying it. Callers
+// must only use it on proofs they have already verified.
+func ProofToHash(pi []byte) ([]byte, error) {
+	gamma, _, _, err := decodeProof(pi)
+	if err != nil {
+		return nil, err
+	}
+	return proofToHash(gamma), nil
+}
+
+// decodeProof is ECVRF_decode_proof from RFC 9381 section 5.4.4. It splits pi
+// into Gamma, the encoded challenge c and s, rejecting s >= q.
+func decodeProof(pi []byte) (*jubjub.Point, []byte, *jubjub.Scalar, error) {
+	if len(pi) != ProofSize {
+		return nil, nil, nil, ErrInvalidProof
+	}
+	gamma, err := curve.Decompress(pi[:32])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidProof
+	}
+	s, err := curve.ScalarFromBytes(pi[32+challengeSize:])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidProof
+	}
+	return gamma, pi[32 : 32+challengeSize], s, nil
+}
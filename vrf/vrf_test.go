@@ -0,0 +1,85 @@
+package vrf
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+)
+
+func TestVRF(t *testing.T) {
+	sk, _ := curve.RandomScalar(nil)
+	pk := PublicKey(sk)
+	alpha := []byte("round 1")
+
+	pi, beta, err := Prove(sk, alpha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pi) != ProofSize || len(beta) != OutputSize {
+		t.Fatal("Prove returned values of the wrong length")
+	}
+
+	out, ok := Verify(pk, alpha, pi)
+	if !ok {
+		t.Fatal("Proof didn't verify")
+	}
+	if !bytes.Equal(out, beta) {
+		t.Error("Verify and Prove disagree on the output")
+	}
+	if out, err := ProofToHash(pi); err != nil || !bytes.Equal(out, beta) {
+		t.Error("ProofToHash disagrees with Prove")
+	}
+
+	if pi2, beta2, _ := Prove(sk, alpha); !bytes.Equal(pi, pi2) || !bytes.Equal(beta, beta2) {
+		t.Error("Prove isn't deterministic")
+	}
+	pi3, beta3, _ := Prove(sk, []byte("round 2"))
+	if bytes.Equal(beta, beta3) || bytes.Equal(pi[32:48], pi3[32:48]) {
+		t.Error("Output or nonce didn't depend on the input")
+	}
+
+	if _, ok := Verify(pk, []byte("round 2"), pi); ok {
+		t.Error("Proof verified for the wrong input")
+	}
+	other, _ := curve.RandomScalar(nil)
+	if _, ok := Verify(PublicKey(other), alpha, pi); ok {
+		t.Error("Proof verified under the wrong key")
+	}
+	for _, i := range []int{0, 40, 70} {
+		bad := append([]byte{}, pi...)
+		bad[i] ^= 1
+		if _, ok := Verify(pk, alpha, bad); ok {
+			t.Errorf("Proof verified with byte %d modified", i)
+		}
+	}
+	// s must be less than the subgroup order.
+	bad := append([]byte{}, pi...)
+	for i := 48; i < ProofSize; i++ {
+		bad[i] = 0xff
+	}
+	if _, ok := Verify(pk, alpha, bad); ok {
+		t.Error("Proof verified with s out of range")
+	}
+	if _, ok := Verify(pk, alpha, pi[:ProofSize-1]); ok {
+		t.Error("Short proof verified")
+	}
+	if _, err := ProofToHash(pi[:ProofSize-1]); err != ErrInvalidProof {
+		t.Error("ProofToHash accepted a short proof")
+	}
+}
+
+func TestVRFRejectsSmallOrderKey(t *testing.T) {
+	zero, _ := curve.ScalarFromBig(new(big.Int))
+	pk := PublicKey(zero)
+	if !pk.IsIdentity() {
+		t.Fatal("Zero key didn't give the identity")
+	}
+
+	pi, _, err := Prove(zero, []byte("alpha"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Verify(pk, []byte("alpha"), pi); ok {
+		t.Error("Proof verified under the identity key")
+	}
+}
@@ -0,0 +1,96 @@
+package jubjub
+
+import (
+	"math/big"
+)
+
+// The Montgomery curve B*v^2 = u^3 + A*u^2 + u is isomorphic to the short
+// Weierstrass curve y^2 = x^3 + a*x + b with
+//  a = (3 - A^2) / (3*B^2), b = (2*A^3 - 9*A) / (27*B^3)
+// under the maps
+//  (x, y) = ((u + A/3) / B, v / B)
+//  (u, v) = (B*x - A/3, B*y)
+// Composing with the Montgomery maps gives the Weierstrass form of the curve.
+
+// WeierstrassParams returns the coefficients a and b of the curve's short
+// Weierstrass form.
+func (curve *Jubjub) WeierstrassParams() (a, b *FieldElement) {
+	A, B := curve.MontgomeryParams()
+	three := curve.newFieldElement(big.NewInt(3))
+
+	AA := curve.newFieldElement(nil).Mul(A, A)
+	BB := curve.newFieldElement(nil).Mul(B, B)
+
+	// a = (3 - A^2) / (3*B^2)
+	a = curve.newFieldElement(nil).Mul(three, BB)
+	a.ModInverse(a)
+	a.Mul(a, curve.newFieldElement(nil).Sub(three, AA))
+
+	// b = (2*A^3 - 9*A) / (27*B^3)
+	b = curve.newFieldElement(big.NewInt(27))
+	b.Mul(b, BB).Mul(b, B).ModInverse(b)
+	num := curve.newFieldElement(big.NewInt(2))
+	num.Mul(num, AA).Sub(num, curve.newFieldElement(big.NewInt(9))).Mul(num, A)
+	b.Mul(b, num)
+
+	return a, b
+}
+
+// montgomeryShift returns A/3 and 1/B, the constants of the map between the
+// Montgomery and Weierstrass forms.
+func (curve *Jubjub) montgomeryShift() (aOver3, bInv *FieldElement) {
+	A, B := curve.MontgomeryParams()
+	aOver3 = curve.newFieldElement(big.NewInt(3))
+	aOver3.ModInverse(aOver3).Mul(aOver3, A)
+	bInv = curve.newFieldElement(nil).ModInverse(B)
+	return aOver3, bInv
+}
+
+// isOnWeierstrass reports whether (x, y) satisfies y^2 = x^3 + a*x + b.
+func (curve *Jubjub) isOnWeierstrass(x, y *FieldElement) bool {
+	a, b := curve.WeierstrassParams()
+
+	lhs := curve.newFieldElement(nil).Mul(y, y)
+	rhs := curve.newFieldElement(nil).Mul(x, x)
+	rhs.Add(rhs, a).Mul(rhs, x).Add(rhs, b)
+
+	return lhs.Equals(rhs)
+}
+
+// ToWeierstrass returns the coordinates of p on the short Weierstrass form of
+// the curve. The identity maps to the point at infinity, which has no affine
+// coordinates; for it ToWeierstrass returns nil coordinates and inf = true.
+func (p *Point) ToWeierstrass() (x, y *FieldElement, inf bool) {
+	u, v, err := p.ToMontgomery()
+	if err != nil {
+		// Only the identity has no Montgomery coordinates.
+		return nil, nil, true
+	}
+
+	aOver3, bInv := p.curve.montgomeryShift()
+	x = u.Add(u, aOver3).Mul(u, bInv)
+	y = v.Mul(v, bInv)
+	return x, y, false
+}
+
+// FromWeierstrass returns the twisted Edwards point corresponding to (x, y) on
+// the short Weierstrass form of the curve, or the identity if inf is set, in
+// which case x and y are ignored. It returns ErrInvalidPoint if (x, y) is not
+// on the Weierstrass curve or corresponds to a point at infinity of the
+// Edwards form.
+func (curve *Jubjub) FromWeierstrass(x, y *FieldElement, inf bool) (*Point, error) {
+	if inf {
+		return curve.Identity(), nil
+	}
+	if !curve.isOnWeierstrass(x, y) {
+		return nil, ErrInvalidPoint
+	}
+
+	_, B := curve.MontgomeryParams()
+	aOver3, _ := curve.montgomeryShift()
+
+	u := curve.newFieldElement(nil).Mul(B, x)
+	u.Sub(u, aOver3)
+	v := curve.newFieldElement(nil).Mul(B, y)
+	return curve.FromMontgomery(u, v)
+}
@@ -0,0 +1,108 @@
+package jubjub
+
+import (
+	"math/big"
+	"testing"
+)
+
+// weierstrassAdd adds two distinct affine points of the curve's short
+// Weierstrass form with the textbook chord-and-tangent formulas.
+func weierstrassAdd(curve *Jubjub, x1, y1, x2, y2 *FieldElement) (x3, y3 *FieldElement) {
+	lambda := curve.newFieldElement(nil)
+	if x1.Equals(x2) {
+		// lambda = (3*x1^2 + a) / (2*y1)
+		a, _ := curve.WeierstrassParams()
+		lambda.Mul(x1, x1)
+		lambda.Add(lambda, lambda).Add(lambda, curve.newFieldElement(nil).Mul(x1, x1)).Add(lambda, a)
+		den := curve.newFieldElement(nil).Add(y1, y1)
+		lambda.Mul(lambda, den.ModInverse(den))
+	} else {
+		// lambda = (y2 - y1) / (x2 - x1)
+		den := curve.newFieldElement(nil).Sub(x2, x1)
+		lambda.Sub(y2, y1).Mul(lambda, den.ModInverse(den))
+	}
+
+	x3 = curve.newFieldElement(nil).Mul(lambda, lambda)
+	x3.Sub(x3, x1).Sub(x3, x2)
+	y3 = curve.newFieldElement(nil).Sub(x1, x3)
+	y3.Mul(y3, lambda).Sub(y3, y1)
+	return x3, y3
+}
+
+func TestWeierstrassConversion(t *testing.T) {
+	for _, params := range []*CurveParams{JubjubParams(), BabyJubjubParams(), BandersnatchParams(), EdBLS12377Params()} {
+		curve, _ := NewCurve(params)
+
+		g := curve.Generator()
+		p := curve.Double(g)
+		for i := 0; i < 4; i++ {
+			x, y, inf := p.ToWeierstrass()
+			if inf {
+				t.Fatalf("%s: point %d mapped to infinity", params.Name, i)
+			}
+			if !curve.isOnWeierstrass(x, y) {
+				t.Errorf("%s: image of point %d isn't on the Weierstrass curve", params.Name, i)
+			}
+			q, err := curve.FromWeierstrass(x, y, false)
+			if err != nil || !q.Equals(p) {
+				t.Errorf("%s: point %d didn't roundtrip", params.Name, i)
+			}
+
+			// The map must be a homomorphism: W(P + G) = W(P) + W(G).
+			gx, gy, _ := g.ToWeierstrass()
+			sx, sy := weierstrassAdd(curve, x, y, gx, gy)
+			wx, wy, _ := curve.Add(p, g).ToWeierstrass()
+			if !sx.Equals(wx) || !sy.Equals(wy) {
+				t.Errorf("%s: addition doesn't commute with the map for point %d", params.Name, i)
+			}
+			dx, dy := weierstrassAdd(curve, x, y, x, y)
+			wx, wy, _ = curve.Double(p).ToWeierstrass()
+			if !dx.Equals(wx) || !dy.Equals(wy) {
+				t.Errorf("%s: doubling doesn't commute with the map for point %d", params.Name, i)
+			}
+
+			p.Add(p, g)
+		}
+
+		if x, y, inf := curve.Identity().ToWeierstrass(); !inf || x != nil || y != nil {
+			t.Errorf("%s: identity didn't map to infinity", params.Name)
+		}
+		if q, err := curve.FromWeierstrass(nil, nil, true); err != nil || !q.IsIdentity() {
+			t.Errorf("%s: infinity didn't map to the identity", params.Name)
+		}
+		if _, err := curve.FromWeierstrass(curve.fieldOne, curve.fieldOne, false); err != ErrInvalidPoint {
+			t.Errorf("%s: accepted a point off the Weierstrass curve", params.Name)
+		}
+	}
+}
+
+func TestWeierstrassParams(t *testing.T) {
+	// Computed outside this package from the textbook Montgomery to
+	// Weierstrass formulas, and checked there by sampling points of
+	// y^2 = x^3 + a*x + b and confirming that [8r] P is infinity for Jubjub's
+	// published group order 8r.
+	curve := Curve()
+	a, b := curve.WeierstrassParams()
+	wantA, _ := new(big.Int).SetString("52296097456646850916096512823759002727550416093741407922227928430486925478210", 10)
+	wantB, _ := new(big.Int).SetString("48351165704696163914533707656614864561753505123260775585269522553028192119009", 10)
+	if a.n.Cmp(wantA) != 0 || b.n.Cmp(wantB) != 0 {
+		t.Errorf("Incorrect Jubjub Weierstrass parameters a = %v, b = %v", a.n, b.n)
+	}
+
+	// Bandersnatch was chosen for its endomorphism from complex multiplication
+	// by an order of discriminant -8, so its j-invariant is 8000 in every
+	// model, independently of how a and b are derived.
+	bandersnatch, _ := NewCurve(BandersnatchParams())
+	a, b = bandersnatch.WeierstrassParams()
+	// j = 1728 * 4a^3 / (4a^3 + 27b^2)
+	fe := func(n int64) *FieldElement { return bandersnatch.newFieldElement(big.NewInt(n)) }
+	fourA3 := bandersnatch.newFieldElement(nil).Mul(a, a)
+	fourA3.Mul(fourA3, a).Mul(fourA3, fe(4))
+	den := bandersnatch.newFieldElement(nil).Mul(b, b)
+	den.Mul(den, fe(27)).Add(den, fourA3)
+	j := bandersnatch.newFieldElement(nil).Mul(fourA3, fe(1728))
+	j.Mul(j, den.ModInverse(den))
+	if !j.Equals(bandersnatch.newFieldElement(big.NewInt(8000))) {
+		t.Errorf("Bandersnatch j-invariant is %v, not 8000", j.n)
+	}
+}
@@ -0,0 +1,386 @@
+package jubjub
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidSeedLength      = errors.New("seed must be between 32 and 252 bytes")
+	ErrHardenedFromViewingKey = errors.New("cannot derive a hardened child from a viewing key")
+	ErrInvalidExtendedKey     = errors.New("not a valid extended key encoding")
+)
+
+const (
+	// HardenedKeyStart is the first hardened child index.
+	HardenedKeyStart uint32 = 1 << 31
+
+	// ExtendedKeySize is the length of an encoded extended spending or full viewing key.
+	ExtendedKeySize = 1 + 4 + 4 + 32 + 4*32
+)
+
+// ExpandedSpendingKey holds the Sapling spend authorizing key, proof
+// authorizing key and outgoing viewing key (ask, nsk, ovk).
+type ExpandedSpendingKey struct {
+	Ask *Scalar
+	Nsk *Scalar
+	Ovk OutgoingViewingKey
+}
+
+// FullViewingKey is a Sapling full viewing key (ak, nk, ovk).
+type FullViewingKey struct {
+	ViewingKey
+	Ovk OutgoingViewingKey
+}
+
+// DeriveProofGenerationKey derives the proof generation key (ak, nsk) with ak = [ask] G.
+func (curve *Jubjub) DeriveProofGenerationKey(expsk *ExpandedSpendingKey) (*ProofGenerationKey, error) {
+	ak, err := curve.ScalarMult(expsk.Ask, curve.SpendingKeyGenerator())
+	if err != nil {
+		return nil, err
+	}
+	return &ProofGenerationKey{ak, expsk.Nsk}, nil
+}
+
+// DeriveFullViewingKey derives the full viewing key (ak, nk, ovk) of an expanded spending key.
+func (curve *Jubjub) DeriveFullViewingKey(expsk *ExpandedSpendingKey) (*FullViewingKey, error) {
+	pgk, err := curve.DeriveProofGenerationKey(expsk)
+	if err != nil {
+		return nil, err
+	}
+	vk, err := pgk.ViewingKey()
+	if err != nil {
+		return nil, err
+	}
+	return &FullViewingKey{*vk, expsk.Ovk}, nil
+}
+
+// Fingerprint computes BLAKE2b-256("ZcashSaplingFVFP", repr(ak) || repr(nk) || ovk).
+func (fvk *FullViewingKey) Fingerprint() [32]byte {
+	h := newBlake2b(32, "ZcashSaplingFVFP")
+	h.Write(fvk.Ak.Compress())
+	h.Write(fvk.Nk.Compress())
+	h.Write(fvk.Ovk[:])
+
+	var fp [32]byte
+	copy(fp[:], h.Sum(nil))
+	return fp
+}
+
+// DiversifierKey is a ZIP 32 diversifier key, which maps diversifier indices
+// to diversifiers with FF1-AES256 so that a key's addresses are unlinkable.
+type DiversifierKey [32]byte
+
+// Diversifier encrypts the 88-bit index j to obtain the diversifier d_j. Not
+// every diversifier is valid; see ExtendedFullViewingKey.FindAddress.
+func (dk *DiversifierKey) Diversifier(j DiversifierIndex) Diversifier {
+	a, b := newFF1(dk[:]).encrypt(splitBits(j))
+	return Diversifier(joinBits(a, b))
+}
+
+// DiversifierIndex decrypts d to recover the index it was derived from.
+func (dk *DiversifierKey) DiversifierIndex(d Diversifier) DiversifierIndex {
+	a, b := newFF1(dk[:]).decrypt(splitBits(d))
+	return DiversifierIndex(joinBits(a, b))
+}
+
+// extendedKeyHeader holds the fields shared by both kinds of extended key.
+type extendedKeyHeader struct {
+	Depth      byte
+	ParentTag  [4]byte
+	ChildIndex uint32
+	ChainCode  [32]byte
+}
+
+func (hdr *extendedKeyHeader) marshal(out []byte) []byte {
+	out = append(out, hdr.Depth)
+	out = append(out, hdr.ParentTag[:]...)
+	var i [4]byte
+	binary.LittleEndian.PutUint32(i[:], hdr.ChildIndex)
+	out = append(out, i[:]...)
+	return append(out, hdr.ChainCode[:]...)
+}
+
+func (hdr *extendedKeyHeader) unmarshal(in []byte) []byte {
+	hdr.Depth = in[0]
+	copy(hdr.ParentTag[:], in[1:5])
+	hdr.ChildIndex = binary.LittleEndian.Uint32(in[5:9])
+	copy(hdr.ChainCode[:], in[9:41])
+	return in[41:]
+}
+
+// childHeader computes I = PRF^expand(c, prefix || I2LEOSP_32(i)) and returns
+// the child's header along with I_L, from which its key material is derived.
+func (hdr *extendedKeyHeader) childHeader(fvk *FullViewingKey, prefix []byte, i uint32) (*extendedKeyHeader, []byte) {
+	var index [4]byte
+	binary.LittleEndian.PutUint32(index[:], i)
+
+	I := prfExpand(hdr.ChainCode[:], prefix, index[:])
+
+	fp := fvk.Fingerprint()
+	child := &extendedKeyHeader{
+		Depth:      hdr.Depth + 1,
+		ChildIndex: i,
+	}
+	copy(child.ParentTag[:], fp[:4])
+	copy(child.ChainCode[:], I[32:])
+
+	return child, I[:32]
+}
+
+// ExtendedSpendingKey is a ZIP 32 extended spending key.
+type ExtendedSpendingKey struct {
+	curve *Jubjub
+	extendedKeyHeader
+	Expsk ExpandedSpendingKey
+	Dk    DiversifierKey
+}
+
+// ExtendedFullViewingKey is a ZIP 32 extended full viewing key.
+type ExtendedFullViewingKey struct {
+	extendedKeyHeader
+	Fvk FullViewingKey
+	Dk  DiversifierKey
+}
+
+// MasterSpendingKey derives the ZIP 32 master extended spending key from a seed.
+func (curve *Jubjub) MasterSpendingKey(seed []byte) (*ExtendedSpendingKey, error) {
+	if len(seed) < 32 || len(seed) > 252 {
+		return nil, ErrInvalidSeedLength
+	}
+
+	h := newBlake2b(64, "ZcashIP32Sapling")
+	h.Write(seed)
+	I := h.Sum(nil)
+	sk := I[:32]
+
+	xsk := &ExtendedSpendingKey{curve: curve}
+	copy(xsk.ChainCode[:], I[32:])
+	xsk.Expsk.Ask = curve.ScalarFromUniformBytes(prfExpand(sk, []byte{0x00}))
+	xsk.Expsk.Nsk = curve.ScalarFromUniformBytes(prfExpand(sk, []byte{0x01}))
+	copy(xsk.Expsk.Ovk[:], prfExpand(sk, []byte{0x02}))
+	copy(xsk.Dk[:], prfExpand(sk, []byte{0x10}))
+
+	return xsk, nil
+}
+
+// ExtendedFullViewingKey returns the extended full viewing key corresponding to xsk.
+func (xsk *ExtendedSpendingKey) ExtendedFullViewingKey() (*ExtendedFullViewingKey, error) {
+	fvk, err := xsk.curve.DeriveFullViewingKey(&xsk.Expsk)
+	if err != nil {
+		return nil, err
+	}
+	return &ExtendedFullViewingKey{xsk.extendedKeyHeader, *fvk, xsk.Dk}, nil
+}
+
+// Fingerprint returns the fingerprint of the key's full viewing key. Its
+// first four bytes are the tag that children record as their parent.
+func (xsk *ExtendedSpendingKey) Fingerprint() ([32]byte, error) {
+	fvk, err := xsk.curve.DeriveFullViewingKey(&xsk.Expsk)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return fvk.Fingerprint(), nil
+}
+
+// Child derives the child key at index i, which is hardened if i >= HardenedKeyStart.
+func (xsk *ExtendedSpendingKey) Child(i uint32) (*ExtendedSpendingKey, error) {
+	curve := xsk.curve
+
+	fvk, err := curve.DeriveFullViewingKey(&xsk.Expsk)
+	if err != nil {
+		return nil, err
+	}
+
+	var prefix []byte
+	if i >= HardenedKeyStart {
+		prefix = append([]byte{0x11}, xsk.Expsk.Ask.ToBytes()...)
+		prefix = append(prefix, xsk.Expsk.Nsk.ToBytes()...)
+	} else {
+		prefix = append([]byte{0x12}, fvk.Ak.Compress()...)
+		prefix = append(prefix, fvk.Nk.Compress()...)
+	}
+	prefix = append(prefix, xsk.Expsk.Ovk[:]...)
+	prefix = append(prefix, xsk.Dk[:]...)
+
+	hdr, IL := xsk.childHeader(fvk, prefix, i)
+
+	child := &ExtendedSpendingKey{curve: curve, extendedKeyHeader: *hdr}
+	child.Expsk.Ask = curve.ScalarFromUniformBytes(prfExpand(IL, []byte{0x13}))
+	child.Expsk.Ask.Add(child.Expsk.Ask, xsk.Expsk.Ask)
+	child.Expsk.Nsk = curve.ScalarFromUniformBytes(prfExpand(IL, []byte{0x14}))
+	child.Expsk.Nsk.Add(child.Expsk.Nsk, xsk.Expsk.Nsk)
+	copy(child.Expsk.Ovk[:], prfExpand(IL, []byte{0x15}, xsk.Expsk.Ovk[:]))
+	copy(child.Dk[:], prfExpand(IL, []byte{0x16}, xsk.Dk[:]))
+
+	return child, nil
+}
+
+// MarshalBinary encodes the key in the 169-byte ZIP 32 format
+// depth || parent tag || i || c || ask || nsk || ovk || dk.
+func (xsk *ExtendedSpendingKey) MarshalBinary() ([]byte, error) {
+	out := xsk.marshal(make([]byte, 0, ExtendedKeySize))
+	out = append(out, xsk.Expsk.Ask.ToBytes()...)
+	out = append(out, xsk.Expsk.Nsk.ToBytes()...)
+	out = append(out, xsk.Expsk.Ovk[:]...)
+	out = append(out, xsk.Dk[:]...)
+	return out, nil
+}
+
+// ExtendedSpendingKeyFromBytes decodes a 169-byte extended spending key. It
+// rejects encodings of ask or nsk that are not reduced.
+func (curve *Jubjub) ExtendedSpendingKeyFromBytes(in []byte) (*ExtendedSpendingKey, error) {
+	if len(in) != ExtendedKeySize {
+		return nil, ErrInvalidExtendedKey
+	}
+
+	xsk := &ExtendedSpendingKey{curve: curve}
+	in = xsk.unmarshal(in)
+
+	var err error
+	if xsk.Expsk.Ask, err = curve.ScalarFromBytes(in[:32]); err != nil {
+		return nil, ErrInvalidExtendedKey
+	}
+	if xsk.Expsk.Nsk, err = curve.ScalarFromBytes(in[32:64]); err != nil {
+		return nil, ErrInvalidExtendedKey
+	}
+	copy(xsk.Expsk.Ovk[:], in[64:96])
+	copy(xsk.Dk[:], in[96:])
+
+	return xsk, nil
+}
+
+// Fingerprint returns the fingerprint of the key's full viewing key.
+func (xfvk *ExtendedFullViewingKey) Fingerprint() [32]byte {
+	return xfvk.Fvk.Fingerprint()
+}
+
+// Child derives the non-hardened child key at index i, adding [I_ask] G to ak
+// and [I_nsk] H to nk. It returns ErrHardenedFromViewingKey for hardened indices.
+func (xfvk *ExtendedFullViewingKey) Child(i uint32) (*ExtendedFullViewingKey, error) {
+	if i >= HardenedKeyStart {
+		return nil, ErrHardenedFromViewingKey
+	}
+
+	curve := xfvk.Fvk.Ak.curve
+
+	prefix := append([]byte{0x12}, xfvk.Fvk.Ak.Compress()...)
+	prefix = append(prefix, xfvk.Fvk.Nk.Compress()...)
+	prefix = append(prefix, xfvk.Fvk.Ovk[:]...)
+	prefix = append(prefix, xfvk.Dk[:]...)
+
+	hdr, IL := xfvk.childHeader(&xfvk.Fvk, prefix, i)
+
+	iAsk, err := curve.ScalarMult(curve.ScalarFromUniformBytes(prfExpand(IL, []byte{0x13})), curve.SpendingKeyGenerator())
+	if err != nil {
+		return nil, err
+	}
+	iNsk, err := curve.ScalarMult(curve.ScalarFromUniformBytes(prfExpand(IL, []byte{0x14})), curve.ProofGenerationKeyGenerator())
+	if err != nil {
+		return nil, err
+	}
+
+	child := &ExtendedFullViewingKey{extendedKeyHeader: *hdr}
+	child.Fvk.Ak = curve.Add(iAsk, xfvk.Fvk.Ak)
+	child.Fvk.Nk = curve.Add(iNsk, xfvk.Fvk.Nk)
+	copy(child.Fvk.Ovk[:], prfExpand(IL, []byte{0x15}, xfvk.Fvk.Ovk[:]))
+	copy(child.Dk[:], prfExpand(IL, []byte{0x16}, xfvk.Dk[:]))
+
+	return child, nil
+}
+
+// MarshalBinary encodes the key in the 169-byte ZIP 32 format
+// depth || parent tag || i || c || ak || nk || ovk || dk.
+func (xfvk *ExtendedFullViewingKey) MarshalBinary() ([]byte, error) {
+	out := xfvk.marshal(make([]byte, 0, ExtendedKeySize))
+	out = append(out, xfvk.Fvk.Ak.Compress()...)
+	out = append(out, xfvk.Fvk.Nk.Compress()...)
+	out = append(out, xfvk.Fvk.Ovk[:]...)
+	out = append(out, xfvk.Dk[:]...)
+	return out, nil
+}
+
+// ExtendedFullViewingKeyFromBytes decodes a 169-byte extended full viewing key.
+// Both ak and nk must be in the prime-order subgroup, and ak must not be the identity.
+func (curve *Jubjub) ExtendedFullViewingKeyFromBytes(in []byte) (*ExtendedFullViewingKey, error) {
+	if len(in) != ExtendedKeySize {
+		return nil, ErrInvalidExtendedKey
+	}
+
+	xfvk := &ExtendedFullViewingKey{}
+	in = xfvk.unmarshal(in)
+
+	fvk, err := curve.fullViewingKeyFromBytes(in[:96])
+	if err != nil {
+		return nil, ErrInvalidExtendedKey
+	}
+	xfvk.Fvk = *fvk
+	copy(xfvk.Dk[:], in[96:])
+
+	return xfvk, nil
+}
+
+// fullViewingKeyFromBytes decodes repr(ak) || repr(nk) || ovk.
+func (curve *Jubjub) fullViewingKeyFromBytes(in []byte) (*FullViewingKey, error) {
+	ak, err := curve.Decompress(in[:32])
+	if err != nil || !ak.inPrimeSubgroup() || ak.IsIdentity() {
+		return nil, ErrInvalidPoint
+	}
+	nk, err := curve.Decompress(in[32:64])
+	if err != nil || !nk.inPrimeSubgroup() {
+		return nil, ErrInvalidPoint
+	}
+
+	fvk := &FullViewingKey{ViewingKey: ViewingKey{ak, nk}}
+	copy(fvk.Ovk[:], in[64:96])
+	return fvk, nil
+}
+
+// Address returns the payment address for diversifier index j. It returns
+// ErrInvalidDiversifier if d_j has no diversified base.
+func (xfvk *ExtendedFullViewingKey) Address(j DiversifierIndex) (*PaymentAddress, error) {
+	ivk, err := xfvk.Fvk.IncomingViewingKey()
+	if err != nil {
+		return nil, err
+	}
+	return ivk.Address(xfvk.Dk.Diversifier(j))
+}
+
+// FindAddress searches upward from index j for the first index whose
+// diversifier is valid and returns its address along with the index used.
+func (xfvk *ExtendedFullViewingKey) FindAddress(j DiversifierIndex) (*PaymentAddress, DiversifierIndex, error) {
+	ivk, err := xfvk.Fvk.IncomingViewingKey()
+	if err != nil {
+		return nil, j, err
+	}
+
+	f := newFF1(xfvk.Dk[:])
+	for {
+		addr, err := ivk.Address(Diversifier(joinBits(f.encrypt(splitBits(j)))))
+		if err == nil {
+			return addr, j, nil
+		}
+		if err != ErrInvalidDiversifier {
+			return nil, j, err
+		}
+		if !j.increment() {
+			return nil, j, ErrDiversifierSpaceExhausted
+		}
+	}
+}
+
+// DefaultAddress returns the key's default address, which uses the first valid
+// diversifier at or after index 0.
+func (xfvk *ExtendedFullViewingKey) DefaultAddress() (*PaymentAddress, DiversifierIndex, error) {
+	return xfvk.FindAddress(DiversifierIndex{})
+}
+
+// DefaultAddress returns the default address of the key's full viewing key.
+func (xsk *ExtendedSpendingKey) DefaultAddress() (*PaymentAddress, DiversifierIndex, error) {
+	xfvk, err := xsk.ExtendedFullViewingKey()
+	if err != nil {
+		return nil, DiversifierIndex{}, err
+	}
+	return xfvk.DefaultAddress()
+}
@@ -0,0 +1,280 @@
+package jubjub
+
+import (
+	"bytes"
+	"testing"
+)
+
+// From https://github.com/zcash/zcash-test-vectors/blob/master/zcash_test_vectors/sapling/zip32.py
+// The keys are m, m/1, m/1/2', the viewing key of m/1/2', and m/1/2'/3 derived
+// from that viewing key, for the seed 0x00..0x1f.
+var saplingZip32 = []struct {
+	xsk, xfvk, fp, ivk string
+
+	// Diversifiers at indices 0, 1, 2 and 2^88-1, or empty if not valid.
+	d0, d1, d2, dmax string
+}{
+	{
+		xsk:  "000000000000000000d0947c4b03bf72a37ab44f72276d1cf3fdcd7ebf3e73348b7e550d752018668eb6c00c93d36032b9a268e99e86a860776560bf0e83c1a10b51f607c9547425068204ede83b2f1fbd84f9b45d7f996e2ebd0a030ad243b48ed39f748a8821ea06395884890323b9d4933c021db89bcf767df21977b2ff0683848321a4df4afb2177c17cb75b7796afb39f0f3e91c924607da56fa9a20e283509bc8a3ef996a172",
+		xfvk: "000000000000000000d0947c4b03bf72a37ab44f72276d1cf3fdcd7ebf3e73348b7e550d752018668e93442e5feffbff16e7217202dc7306729ffffe85af5683bce2642e3eeb5d3871dce8e7edece04b8950417f85ba57691b783c45b1a27422db1693dceb67b10106395884890323b9d4933c021db89bcf767df21977b2ff0683848321a4df4afb2177c17cb75b7796afb39f0f3e91c924607da56fa9a20e283509bc8a3ef996a172",
+		fp:   "14c2713adce93a830ea83a051908b7447783f5d106c0985e02550e426f27597c",
+		ivk:  "4847a130e799d3dbea36a1c16467d621fb2d80e30b3b1d1a426893415dad6601",
+		d0:   "d8621b981cf300e9d4cc89",
+		d1:   "48ea17a199c84bd1baa5d4",
+	},
+	{
+		xsk:  "0114c2713a010000000147110c691a03b9d9f0ba9005c5e790a595b7f04e3329d2fa438a6705dabce6282bc197a516287c8ea8f68c424abad302b45cdf95407961d7b8b455267a350ce7a32988fdca1efcd6d1c4c562e629c2e96b2c3f7eda04ac4efd1810ff6bba015f1381fc8886da6a02dffeefcf503c40fa8f5a36f7a7142fd81b5518c5a47474e04de832a2d791ec129ab9002b91c9e9cdeed79241a7c4960e5178d870c1b4dc",
+		xfvk: "0114c2713a010000000147110c691a03b9d9f0ba9005c5e790a595b7f04e3329d2fa438a6705dabce6dc14b514d3a92594c21925af2f7765a547b30e73fa7b700ea1bff2e5efaaa88b6152eb7fdb252779ddcb95d217ea4b6fd34036e9adadb3b5c9cbeceb41ba452a5f1381fc8886da6a02dffeefcf503c40fa8f5a36f7a7142fd81b5518c5a47474e04de832a2d791ec129ab9002b91c9e9cdeed79241a7c4960e5178d870c1b4dc",
+		fp:   "db999e071dcb58dd93029ae697053e90edb359d1a1b7a125167efbe928068423",
+		ivk:  "155a8ee205d3872d12f8a3e639914633c23cde1f30ed5051e52130b1d0104c06",
+		d0:   "8b4138320dfafd7b399781",
+		d2:   "5749a13352bc223e308078",
+		dmax: "6389574cde0fbbc6368131",
+	},
+	{
+		xsk:  "02db999e070200008097ce15f4ed1b9739b0262a463bcb3dc9b3bd2323a9baa441ca42777383a8d4358be8113cee3413a71f82c41fc8da517be134049832e6825c92da6b84fee4c60d3778059dc569e7d0d32391573f951bbde92fc6b9cf614773661c5c273aa6990ccf81182e96223c028ce3d6eb4794d3113b95069d14c57588e193b65efc2813bca3eda19f9eff46ca12dfa1bf10371b48d1b4a40c4d05a0d8dce0e7dc62b07b37",
+		xfvk: "02db999e070200008097ce15f4ed1b9739b0262a463bcb3dc9b3bd2323a9baa441ca42777383a8d435a6c5925a0f85fa4f1e405e3a4970d0c4a4b4814438f4e9d4520e20f7fdcf3841304e305916216beb7b654d8aae50ecd188fcb384bc36c00c664f307725e2ee11cf81182e96223c028ce3d6eb4794d3113b95069d14c57588e193b65efc2813bca3eda19f9eff46ca12dfa1bf10371b48d1b4a40c4d05a0d8dce0e7dc62b07b37",
+		fp:   "48c183757b5da6612a81b30e40b4acaa2d9e739512e1d2d0010e92a7f7f2fcdf",
+		ivk:  "a2a13c1e38b45984445803e430a683c90bb2e14d4c8692ff253a6484dd9bb504",
+		d0:   "e8d03793cdd2bacc9c7041",
+		d1:   "020a7a6b0bf84d3e899f68",
+	},
+	{
+		xfvk: "02db999e070200008097ce15f4ed1b9739b0262a463bcb3dc9b3bd2323a9baa441ca42777383a8d435a6c5925a0f85fa4f1e405e3a4970d0c4a4b4814438f4e9d4520e20f7fdcf3841304e305916216beb7b654d8aae50ecd188fcb384bc36c00c664f307725e2ee11cf81182e96223c028ce3d6eb4794d3113b95069d14c57588e193b65efc2813bca3eda19f9eff46ca12dfa1bf10371b48d1b4a40c4d05a0d8dce0e7dc62b07b37",
+		fp:   "48c183757b5da6612a81b30e40b4acaa2d9e739512e1d2d0010e92a7f7f2fcdf",
+		ivk:  "a2a13c1e38b45984445803e430a683c90bb2e14d4c8692ff253a6484dd9bb504",
+		d0:   "e8d03793cdd2bacc9c7041",
+		d1:   "020a7a6b0bf84d3e899f68",
+	},
+	{
+		xfvk: "0348c18375030000008d937bcf81ba430d5b49afc0a403367b1fd99879ecba41be051c5a4aa7d6e7e8b185c57b509c2536c4f2d326d766c8fab25447de5375a9328d649ddabd97a6a3db88049e02d207568afc42e07db2abed500b2701c01bbff36399764b81c0664f69b9e0fa1c4b3deb91d53beee871156121474b8b62ef24134478dc3499691af6becb50c363bb2ed9da5c3043ceb0f1a0527bf836b29a35f7c0c9f261123be56e",
+		fp:   "2e08156df8dfa25b5055fc063c671535a6a65a60437d96e7930815d090f62d67",
+		ivk:  "b0a5f337232f2c3dac70c2a410fa561fc45d8cc59cda246d31c8b1715a57d900",
+		d1:   "030ffb263a939e230e96dd",
+		d2:   "7bbf63934c7e92670cdb55",
+		dmax: "1a730feb0059cf1f5bdea8",
+	},
+}
+
+func TestZip32Derivation(t *testing.T) {
+	curve := Curve()
+
+	seed := make([]byte, 32)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+
+	m, err := curve.MasterSpendingKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m1, err := m.Child(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m12h, err := m1.Child(HardenedKeyStart + 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var xfvks []*ExtendedFullViewingKey
+	for i, xsk := range []*ExtendedSpendingKey{m, m1, m12h} {
+		enc, _ := xsk.MarshalBinary()
+		if want := decodeHex(t, saplingZip32[i].xsk); !bytes.Equal(enc, want) {
+			t.Errorf("Incorrect xsk for test %d:\nWant: %x\nHave: %x", i, want, enc)
+		}
+
+		xfvk, err := xsk.ExtendedFullViewingKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		xfvks = append(xfvks, xfvk)
+	}
+
+	// m/1/2' is listed twice: once as a spending key and once as a viewing key.
+	xfvks = append(xfvks, xfvks[2])
+	m12h3, err := xfvks[3].Child(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xfvks = append(xfvks, m12h3)
+
+	for i, xfvk := range xfvks {
+		tv := saplingZip32[i]
+
+		enc, _ := xfvk.MarshalBinary()
+		if want := decodeHex(t, tv.xfvk); !bytes.Equal(enc, want) {
+			t.Errorf("Incorrect xfvk for test %d:\nWant: %x\nHave: %x", i, want, enc)
+		}
+
+		fp := xfvk.Fingerprint()
+		if want := decodeHex(t, tv.fp); !bytes.Equal(fp[:], want) {
+			t.Errorf("Incorrect fingerprint for test %d:\nWant: %x\nHave: %x", i, want, fp)
+		}
+
+		ivk, err := xfvk.Fvk.IncomingViewingKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := decodeHex(t, tv.ivk); !bytes.Equal(ivk.Scalar().ToBytes(), want) {
+			t.Errorf("Incorrect ivk for test %d:\nWant: %x\nHave: %x", i, want, ivk.Scalar().ToBytes())
+		}
+	}
+
+	// Non-hardened derivation commutes with taking the viewing key.
+	m13, _ := m1.Child(3)
+	fromXsk, _ := m13.ExtendedFullViewingKey()
+	xfvk1, _ := m1.ExtendedFullViewingKey()
+	fromXfvk, err := xfvk1.Child(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := fromXsk.MarshalBinary()
+	b, _ := fromXfvk.MarshalBinary()
+	if !bytes.Equal(a, b) {
+		t.Error("Non-hardened child of the xfvk doesn't match the xsk's child")
+	}
+
+	if _, err := xfvk1.Child(HardenedKeyStart); err != ErrHardenedFromViewingKey {
+		t.Error("Derived a hardened child from a viewing key")
+	}
+	if _, err := curve.MasterSpendingKey(seed[:31]); err != ErrInvalidSeedLength {
+		t.Error("Accepted a short seed")
+	}
+}
+
+func TestZip32Encoding(t *testing.T) {
+	curve := Curve()
+	for i, tv := range saplingZip32 {
+		if tv.xsk != "" {
+			enc := decodeHex(t, tv.xsk)
+			xsk, err := curve.ExtendedSpendingKeyFromBytes(enc)
+			if err != nil {
+				t.Fatalf("Couldn't decode xsk %d: %v", i, err)
+			}
+			if out, _ := xsk.MarshalBinary(); !bytes.Equal(out, enc) {
+				t.Errorf("xsk %d didn't roundtrip", i)
+			}
+
+			// An ask of all ones is larger than the subgroup order.
+			bad := append([]byte{}, enc...)
+			for j := 41; j < 73; j++ {
+				bad[j] = 0xff
+			}
+			if _, err := curve.ExtendedSpendingKeyFromBytes(bad); err != ErrInvalidExtendedKey {
+				t.Errorf("Accepted a non-canonical ask for xsk %d", i)
+			}
+		}
+
+		enc := decodeHex(t, tv.xfvk)
+		xfvk, err := curve.ExtendedFullViewingKeyFromBytes(enc)
+		if err != nil {
+			t.Fatalf("Couldn't decode xfvk %d: %v", i, err)
+		}
+		if out, _ := xfvk.MarshalBinary(); !bytes.Equal(out, enc) {
+			t.Errorf("xfvk %d didn't roundtrip", i)
+		}
+
+		if _, err := curve.ExtendedFullViewingKeyFromBytes(enc[1:]); err != ErrInvalidExtendedKey {
+			t.Errorf("Accepted a truncated xfvk %d", i)
+		}
+	}
+}
+
+func TestDiversifierKey(t *testing.T) {
+	curve := Curve()
+
+	var max DiversifierIndex
+	for i := range max {
+		max[i] = 0xff
+	}
+	indices := []DiversifierIndex{{0}, {1}, {2}, max}
+
+	for i, tv := range saplingZip32 {
+		xfvk, err := curve.ExtendedFullViewingKeyFromBytes(decodeHex(t, tv.xfvk))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		firstValid := -1
+		for k, want := range []string{tv.d0, tv.d1, tv.d2, tv.dmax} {
+			j := indices[k]
+			d := xfvk.Dk.Diversifier(j)
+
+			if xfvk.Dk.DiversifierIndex(d) != j {
+				t.Errorf("Diversifier %d of test %d didn't decrypt to its index", k, i)
+			}
+
+			_, err := xfvk.Address(j)
+			if want == "" {
+				if err != ErrInvalidDiversifier {
+					t.Errorf("Accepted invalid diversifier %d for test %d", k, i)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("Rejected diversifier %d for test %d: %v", k, i, err)
+			}
+			if !bytes.Equal(d[:], decodeHex(t, want)) {
+				t.Errorf("Incorrect diversifier %d for test %d:\nWant: %s\nHave: %x", k, i, want, d)
+			}
+			if firstValid < 0 {
+				firstValid = k
+			}
+		}
+
+		addr, j, err := xfvk.DefaultAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j != indices[firstValid] {
+			t.Errorf("Incorrect default index for test %d: %x", i, j)
+		}
+		if addr.Diversifier != xfvk.Dk.Diversifier(j) {
+			t.Errorf("Default address for test %d doesn't use d_j", i)
+		}
+	}
+}
+
+// From the FF1-AES256 tests in
+// https://github.com/zcash/zcash-test-vectors/blob/master/zcash_test_vectors/ff1.py
+func TestFF1(t *testing.T) {
+	key := decodeHex(t, "2b7e151628aed2a6abf7158809cf4f3cef4359d8d580aa4f7f036d6f04fc6a94")
+
+	vectors := []struct{ pt, ct string }{
+		{
+			pt: "0000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+			ct: "0000100100110101011101111111110011000001101100111110011101110101011010100100010011001111",
+		},
+		{
+			pt: "0000100100110101011101111111110011000001101100111110011101110101011010100100010011001111",
+			ct: "1101101011010001100011110000010011001111110110011101010110100001111001000101011111011000",
+		},
+		{
+			pt: "0101010101010101010101010101010101010101010101010101010101010101010101010101010101010101",
+			ct: "0000111101000001111011010111011111110001100101000000001101101110100010010111001100100110",
+		},
+	}
+
+	// The vectors list bits in order, which this package packs least significant bit first.
+	pack := func(s string) [DiversifierLength]byte {
+		var out [DiversifierLength]byte
+		for k, c := range s {
+			if c == '1' {
+				out[k/8] |= 1 << uint(k%8)
+			}
+		}
+		return out
+	}
+
+	f := newFF1(key)
+	for i, tv := range vectors {
+		pt, ct := pack(tv.pt), pack(tv.ct)
+		if have := joinBits(f.encrypt(splitBits(pt))); have != ct {
+			t.Errorf("Incorrect encryption for test %d: want %x, have %x", i, ct, have)
+		}
+		if have := joinBits(f.decrypt(splitBits(ct))); have != pt {
+			t.Errorf("Incorrect decryption for test %d: want %x, have %x", i, pt, have)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package zkp
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/gtank/jubjub"
+)
+
+// DLEQProofSize is the length of an encoded DLEQProof, R1 || R2 || s.
+const DLEQProofSize = 96
+
+// DLEQ is the Chaum–Pedersen proof that two points share a discrete log.
+type DLEQ struct {
+	curve *jubjub.Jubjub
+}
+
+// DLEQStatement claims that log_G(A) = log_H(B).
+type DLEQStatement struct {
+	G, H *jubjub.Point
+	A, B *jubjub.Point
+}
+
+// DLEQProof is a non-interactive proof that [s] G = R1 + [c] A and
+// [s] H = R2 + [c] B, where the challenge c is derived from the transcript,
+// the statement, R1 and R2. Keeping the commitments rather than c lets many
+// proofs be checked at once.
+type DLEQProof struct {
+	R1, R2 *jubjub.Point
+	S      *jubjub.Scalar
+}
+
+// NewDLEQ returns DLEQ proofs over the curve.
+func NewDLEQ(curve *jubjub.Jubjub) *DLEQ {
+	return &DLEQ{curve}
+}
+
+// Statement returns the statement with A = [x] G and B = [x] H. It returns
+// ErrInvalidStatement if G or H is outside the prime-order subgroup.
+func (d *DLEQ) Statement(G, H *jubjub.Point, x *jubjub.Scalar) (*DLEQStatement, error) {
+	if !G.IsInPrimeSubgroup() || !H.IsInPrimeSubgroup() {
+		return nil, ErrInvalidStatement
+	}
+	A, err := d.curve.ScalarMult(x, G)
+	if err != nil {
+		return nil, err
+	}
+	B, err := d.curve.ScalarMult(x, H)
+	if err != nil {
+		return nil, err
+	}
+	return &DLEQStatement{G.Clone(), H.Clone(), A, B}, nil
+}
+
+// inPrimeSubgroup reports whether every point of st is in the prime-order
+// subgroup, and is false for a nil statement or point. Points already found to
+// be in it are recorded in checked, keyed by their encoding, so that points
+// shared between statements are checked once.
+func (st *DLEQStatement) inPrimeSubgroup(checked map[string]bool) bool {
+	if st == nil {
+		return false
+	}
+	for _, p := range []*jubjub.Point{st.G, st.H, st.A, st.B} {
+		if p == nil {
+			return false
+		}
+		key := string(p.Compress())
+		if checked[key] {
+			continue
+		}
+		if !p.IsInPrimeSubgroup() {
+			return false
+		}
+		checked[key] = true
+	}
+	return true
+}
+
+// appendStatement absorbs the protocol name and the statement.
+func (d *DLEQ) appendStatement(t *Transcript, st *DLEQStatement) error {
+	t.AppendMessage("dom-sep", []byte("dleq"))
+	return t.appendPoints([]string{"G", "H", "A", "B"}, st.G, st.H, st.A, st.B)
+}
+
+// challenge absorbs the commitments R1 and R2 and returns c.
+func (d *DLEQ) challenge(t *Transcript, R1, R2 *jubjub.Point) (*jubjub.Scalar, error) {
+	if err := t.appendPoints([]string{"R1", "R2"}, R1, R2); err != nil {
+		return nil, err
+	}
+	return t.ChallengeScalar(d.curve, "c"), nil
+}
+
+// Prove returns a proof of st given its witness x, absorbing the statement into
+// t. The nonce is hedged from t, x and random, which defaults to crypto/rand if
+// nil.
+func (d *DLEQ) Prove(t *Transcript, st *DLEQStatement, x *jubjub.Scalar, random io.Reader) (*DLEQProof, error) {
+	if err := d.appendStatement(t, st); err != nil {
+		return nil, err
+	}
+
+	k, err := t.witnessScalar(d.curve, x.ToBytes(), random)
+	if err != nil {
+		return nil, err
+	}
+	return d.prove(t, st, x, k)
+}
+
+// ProveDeterministic is like Prove, but derives the nonce from t, the statement
+// and x alone, so that proving the same statement under the same transcript
+// twice gives the same proof. It needs no randomness.
+func (d *DLEQ) ProveDeterministic(t *Transcript, st *DLEQStatement, x *jubjub.Scalar) (*DLEQProof, error) {
+	if err := d.appendStatement(t, st); err != nil {
+		return nil, err
+	}
+	return d.prove(t, st, x, t.deriveWitnessScalar(d.curve, x.ToBytes(), nil))
+}
+
+// prove finishes a proof of st, already absorbed into t, with the nonce k.
+func (d *DLEQ) prove(t *Transcript, st *DLEQStatement, x, k *jubjub.Scalar) (*DLEQProof, error) {
+	R1, err := d.curve.ScalarMult(k, st.G)
+	if err != nil {
+		return nil, err
+	}
+	R2, err := d.curve.ScalarMult(k, st.H)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := d.challenge(t, R1, R2)
+	if err != nil {
+		return nil, err
+	}
+
+	// s = k + c * x
+	s := newScalar(d.curve)
+	s.Mul(c, x).Add(s, k)
+
+	return &DLEQProof{R1, R2, s}, nil
+}
+
+// Verify reports whether proof is valid for st, absorbing the statement into t
+// as Prove did. It rejects statements with any point outside the prime-order
+// subgroup, where equal logs modulo the cofactor don't imply equal logs. Both
+// equations are multiplied by the cofactor, which ignores small-order
+// components of R1 and R2 and makes Verify agree with BatchVerify.
+//
+// A nil statement, proof, or field of either is rejected rather than
+// dereferenced.
+func (d *DLEQ) Verify(t *Transcript, st *DLEQStatement, proof *DLEQProof) bool {
+	if !proof.complete() || !st.inPrimeSubgroup(make(map[string]bool)) {
+		return false
+	}
+	if err := d.appendStatement(t, st); err != nil {
+		return false
+	}
+	c, err := d.challenge(t, proof.R1, proof.R2)
+	if err != nil {
+		return false
+	}
+
+	one, _ := d.curve.ScalarFromBig(big.NewInt(1))
+	for _, eq := range []struct {
+		base, pub, R *jubjub.Point
+	}{{st.G, st.A, proof.R1}, {st.H, st.B, proof.R2}} {
+		// [h]([s] base - R - [c] pub) = O
+		check, err := d.curve.MultiScalarMult(
+			[]*jubjub.Scalar{proof.S, one, c},
+			[]*jubjub.Point{eq.base, negate(eq.R), negate(eq.pub)},
+		)
+		if err != nil || !check.MulByCofactor().IsIdentity() {
+			return false
+		}
+	}
+	return true
+}
+
+// complete reports whether proof and all of its fields are non-nil.
+func (proof *DLEQProof) complete() bool {
+	return proof != nil && proof.R1 != nil && proof.R2 != nil && proof.S != nil
+}
+
+// BatchVerify reports whether every proof is valid for the statement and
+// transcript at the same index, absorbing each statement into its transcript.
+// It combines all 2n equations with random weights read from random, which
+// defaults to crypto/rand if nil, into a single multi-scalar multiplication
+// that is much cheaper than n calls to Verify. If it returns false, at least
+// one proof is invalid, but the batch doesn't say which.
+//
+// Like Verify, it rejects statements with points outside the prime-order
+// subgroup. Those checks can't be batched, but each distinct point is only
+// checked once, so statements that share G and H cost two checks each.
+func (d *DLEQ) BatchVerify(ts []*Transcript, statements []*DLEQStatement, proofs []*DLEQProof, random io.Reader) bool {
+	if len(ts) != len(statements) || len(ts) != len(proofs) {
+		return false
+	}
+
+	checked := make(map[string]bool)
+	var scalars []*jubjub.Scalar
+	var points []*jubjub.Point
+	for i, st := range statements {
+		proof := proofs[i]
+		if !proof.complete() || !st.inPrimeSubgroup(checked) {
+			return false
+		}
+		if err := d.appendStatement(ts[i], st); err != nil {
+			return false
+		}
+		c, err := d.challenge(ts[i], proof.R1, proof.R2)
+		if err != nil {
+			return false
+		}
+
+		for _, eq := range []struct {
+			base, pub, R *jubjub.Point
+		}{{st.G, st.A, proof.R1}, {st.H, st.B, proof.R2}} {
+			z, err := d.curve.RandomScalar(random)
+			if err != nil {
+				return false
+			}
+
+			// z * ([s] base - R - [c] pub)
+			scalars = append(scalars,
+				newScalar(d.curve).Mul(z, proof.S),
+				z,
+				newScalar(d.curve).Mul(z, c),
+			)
+			points = append(points, eq.base, negate(eq.R), negate(eq.pub))
+		}
+	}
+
+	check, err := d.curve.MultiScalarMult(scalars, points)
+	if err != nil {
+		return false
+	}
+	return check.MulByCofactor().IsIdentity()
+}
+
+// negate returns -p as a newly allocated point.
+func negate(p *jubjub.Point) *jubjub.Point {
+	n := p.Clone()
+	return n.Neg(n)
+}
+
+// MarshalBinary encodes the proof as the compressed R1 and R2 followed by s.
+func (proof *DLEQProof) MarshalBinary() ([]byte, error) {
+	R1, err := proof.R1.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	R2, err := proof.R2.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(append(R1, R2...), proof.S.ToBytes()...), nil
+}
+
+// DLEQProofFromBytes decodes a 96-byte proof on the curve. R1 and R2 must be
+// valid point encodings and s must be less than the subgroup order.
+func DLEQProofFromBytes(curve *jubjub.Jubjub, in []byte) (*DLEQProof, error) {
+	if len(in) != DLEQProofSize {
+		return nil, ErrInvalidProof
+	}
+	R1, err := curve.Decompress(in[:32])
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	R2, err := curve.Decompress(in[32:64])
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	S, err := curve.ScalarFromBytes(in[64:])
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	return &DLEQProof{R1, R2, S}, nil
+}
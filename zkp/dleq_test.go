@@ -0,0 +1,168 @@
+package zkp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gtank/jubjub"
+)
+
+func TestDLEQ(t *testing.T) {
+	curve := jubjub.Curve()
+	dleq := NewDLEQ(curve)
+
+	H, _ := curve.RandomSubgroupPoint(nil)
+	x, _ := curve.RandomScalar(nil)
+	st, err := dleq.Statement(curve.SubgroupGenerator(), H, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := dleq.Prove(NewTranscript("test"), st, x, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dleq.Verify(NewTranscript("test"), st, proof) {
+		t.Fatal("Proof didn't verify")
+	}
+	if dleq.Verify(NewTranscript("other"), st, proof) {
+		t.Error("Proof verified under a different transcript")
+	}
+
+	y, _ := curve.RandomScalar(nil)
+	B, _ := curve.ScalarMult(y, H)
+	bad := &DLEQStatement{st.G, st.H, st.A, B}
+	if dleq.Verify(NewTranscript("test"), bad, proof) {
+		t.Error("Proof verified for unequal logs")
+	}
+	if p, _ := dleq.Prove(NewTranscript("test"), bad, x, nil); dleq.Verify(NewTranscript("test"), bad, p) {
+		t.Error("Proved a false statement")
+	}
+
+	for name, bad := range map[string]*DLEQProof{
+		"nil proof": nil,
+		"nil R1":    {nil, proof.R2, proof.S},
+		"nil R2":    {proof.R1, nil, proof.S},
+		"nil s":     {proof.R1, proof.R2, nil},
+	} {
+		if dleq.Verify(NewTranscript("test"), st, bad) {
+			t.Errorf("Proof verified with %s", name)
+		}
+		if dleq.BatchVerify([]*Transcript{NewTranscript("test")}, []*DLEQStatement{st}, []*DLEQProof{bad}, nil) {
+			t.Errorf("Batch verified with %s", name)
+		}
+	}
+	for name, bad := range map[string]*DLEQStatement{
+		"nil statement": nil,
+		"nil G":         {nil, st.H, st.A, st.B},
+		"nil B":         {st.G, st.H, st.A, nil},
+	} {
+		if dleq.Verify(NewTranscript("test"), bad, proof) {
+			t.Errorf("Proof verified for a statement with %s", name)
+		}
+		if dleq.BatchVerify([]*Transcript{NewTranscript("test")}, []*DLEQStatement{bad}, []*DLEQProof{proof}, nil) {
+			t.Errorf("Batch verified for a statement with %s", name)
+		}
+	}
+
+	// Repeated randomness must not repeat the nonce across statements.
+	fixed := bytes.Repeat([]byte{0x42}, 32)
+	H2, _ := curve.RandomSubgroupPoint(nil)
+	st2, _ := dleq.Statement(curve.SubgroupGenerator(), H2, x)
+	p1, _ := dleq.Prove(NewTranscript("test"), st, x, bytes.NewReader(fixed))
+	p2, _ := dleq.Prove(NewTranscript("test"), st2, x, bytes.NewReader(fixed))
+	if p1.R1.Equals(p2.R1) {
+		t.Error("Nonce didn't depend on the statement")
+	}
+
+	// Deterministic proofs repeat for one statement and differ between them.
+	d1, err := dleq.ProveDeterministic(NewTranscript("test"), st, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dleq.Verify(NewTranscript("test"), st, d1) {
+		t.Error("Deterministic proof didn't verify")
+	}
+	if d2, _ := dleq.ProveDeterministic(NewTranscript("test"), st, x); !d2.R1.Equals(d1.R1) || !d2.S.Equals(d1.S) {
+		t.Error("ProveDeterministic isn't deterministic")
+	}
+	if d3, _ := dleq.ProveDeterministic(NewTranscript("test"), st2, x); d3.R1.Equals(d1.R1) {
+		t.Error("Deterministic nonce didn't depend on the statement")
+	}
+
+	enc, err := proof.MarshalBinary()
+	if err != nil || len(enc) != DLEQProofSize {
+		t.Fatal("Proof encoding has the wrong length")
+	}
+	decoded, err := DLEQProofFromBytes(curve, enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dleq.Verify(NewTranscript("test"), st, decoded) {
+		t.Error("Decoded proof didn't verify")
+	}
+	if _, err := DLEQProofFromBytes(curve, enc[:95]); err != ErrInvalidProof {
+		t.Error("Decoded a short proof")
+	}
+}
+
+func TestDLEQBatchVerify(t *testing.T) {
+	curve := jubjub.Curve()
+	dleq := NewDLEQ(curve)
+
+	var statements []*DLEQStatement
+	var proofs []*DLEQProof
+	transcripts := func() []*Transcript {
+		ts := make([]*Transcript, len(statements))
+		for i := range ts {
+			ts[i] = NewTranscript("batch")
+		}
+		return ts
+	}
+
+	x, _ := curve.RandomScalar(nil)
+	for i := 0; i < 4; i++ {
+		H, _ := curve.RandomSubgroupPoint(nil)
+		st, _ := dleq.Statement(curve.SubgroupGenerator(), H, x)
+		proof, err := dleq.Prove(NewTranscript("batch"), st, x, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		statements = append(statements, st)
+		proofs = append(proofs, proof)
+	}
+
+	if !dleq.BatchVerify(transcripts(), statements, proofs, nil) {
+		t.Fatal("Valid batch didn't verify")
+	}
+	if !dleq.BatchVerify(nil, nil, nil, nil) {
+		t.Error("Empty batch didn't verify")
+	}
+	if dleq.BatchVerify(transcripts()[1:], statements, proofs, nil) {
+		t.Error("Batch verified with a missing transcript")
+	}
+
+	// Swapping two proofs breaks both statements.
+	proofs[0], proofs[1] = proofs[1], proofs[0]
+	if dleq.BatchVerify(transcripts(), statements, proofs, nil) {
+		t.Error("Batch with invalid proofs verified")
+	}
+	proofs[0], proofs[1] = proofs[1], proofs[0]
+
+	// A small-order component in the statement makes it false, since B is no
+	// longer [x] H, so both checks must reject it even though the cofactored
+	// equations hold.
+	small := smallOrderPoint(t, curve)
+	statements[2].B.Add(statements[2].B, small)
+	proofs[2], _ = dleq.Prove(NewTranscript("batch"), statements[2], x, nil)
+	if dleq.Verify(NewTranscript("batch"), statements[2], proofs[2]) {
+		t.Error("Verify accepted a statement outside the prime-order subgroup")
+	}
+	if dleq.BatchVerify(transcripts(), statements, proofs, nil) {
+		t.Error("BatchVerify accepted a statement outside the prime-order subgroup")
+	}
+
+	if _, err := dleq.Statement(curve.SubgroupGenerator(), small, x); err != ErrInvalidStatement {
+		t.Errorf("Built a statement on a small-order base: %v", err)
+	}
+}
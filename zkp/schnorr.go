@@ -0,0 +1,146 @@
+package zkp
+
+import (
+	"io"
+
+	"github.com/gtank/jubjub"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidProof     = errors.New("not a valid proof encoding")
+	ErrInvalidStatement = errors.New("statement has a point outside the prime-order subgroup")
+)
+
+// SchnorrProofSize is the length of an encoded SchnorrProof, R || s.
+const SchnorrProofSize = 64
+
+// Schnorr proves knowledge of x such that P = [x] G for a fixed base G.
+type Schnorr struct {
+	curve *jubjub.Jubjub
+	base  *jubjub.Point
+}
+
+// SchnorrProof is a non-interactive proof that [s] G = R + [c] P, where the
+// challenge c is derived from the transcript, G, P and R.
+type SchnorrProof struct {
+	R *jubjub.Point
+	S *jubjub.Scalar
+}
+
+// NewSchnorr returns Schnorr proofs over the given base point, which should
+// generate the prime-order subgroup.
+func NewSchnorr(curve *jubjub.Jubjub, base *jubjub.Point) *Schnorr {
+	return &Schnorr{curve, base.Clone()}
+}
+
+// appendStatement absorbs the protocol name, G and P.
+func (s *Schnorr) appendStatement(t *Transcript, P *jubjub.Point) error {
+	t.AppendMessage("dom-sep", []byte("schnorr"))
+	return t.appendPoints([]string{"G", "P"}, s.base, P)
+}
+
+// challenge absorbs the commitment R and returns c.
+func (s *Schnorr) challenge(t *Transcript, R *jubjub.Point) (*jubjub.Scalar, error) {
+	if err := t.AppendPoint("R", R); err != nil {
+		return nil, err
+	}
+	return t.ChallengeScalar(s.curve, "c"), nil
+}
+
+// Prove returns the public point P = [x] G and a proof of knowledge of x,
+// absorbing them into t. The nonce is hedged from t, x and random, which
+// defaults to crypto/rand if nil.
+func (s *Schnorr) Prove(t *Transcript, x *jubjub.Scalar, random io.Reader) (*jubjub.Point, *SchnorrProof, error) {
+	P, err := s.curve.ScalarMult(x, s.base)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.appendStatement(t, P); err != nil {
+		return nil, nil, err
+	}
+
+	k, err := t.witnessScalar(s.curve, x.ToBytes(), random)
+	if err != nil {
+		return nil, nil, err
+	}
+	R, err := s.curve.ScalarMult(k, s.base)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := s.challenge(t, R)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// s = k + c * x
+	z := newScalar(s.curve)
+	z.Mul(c, x).Add(z, k)
+
+	return P, &SchnorrProof{R, z}, nil
+}
+
+// Verify reports whether proof shows knowledge of the discrete log of P to the
+// base G, absorbing the statement into t as Prove did. It rejects P outside the
+// prime-order subgroup, where a prover who doesn't know its discrete log could
+// still find a passing proof by retrying small-order offsets in R.
+//
+// Unlike DLEQ, the check [s] G = R + [c] P is not multiplied by the cofactor.
+// DLEQ needs that so Verify agrees with BatchVerify; here there is no batch to
+// agree with, and with G and P in the subgroup, an R with a small-order
+// component already fails the strict check.
+//
+// A nil P, proof, R or s is rejected rather than dereferenced.
+func (s *Schnorr) Verify(t *Transcript, P *jubjub.Point, proof *SchnorrProof) bool {
+	if P == nil || proof == nil || proof.R == nil || proof.S == nil {
+		return false
+	}
+	if !P.IsInPrimeSubgroup() {
+		return false
+	}
+	if err := s.appendStatement(t, P); err != nil {
+		return false
+	}
+	c, err := s.challenge(t, proof.R)
+	if err != nil {
+		return false
+	}
+
+	lhs, err := s.curve.ScalarMult(proof.S, s.base)
+	if err != nil {
+		return false
+	}
+	rhs, err := s.curve.ScalarMult(c, P)
+	if err != nil {
+		return false
+	}
+	return lhs.Equals(rhs.Add(rhs, proof.R))
+}
+
+// MarshalBinary encodes the proof as the compressed R followed by s.
+func (proof *SchnorrProof) MarshalBinary() ([]byte, error) {
+	R, err := proof.R.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(R, proof.S.ToBytes()...), nil
+}
+
+// SchnorrProofFromBytes decodes a 64-byte proof on the curve. R must be a
+// valid point encoding and s must be less than the subgroup order.
+func SchnorrProofFromBytes(curve *jubjub.Jubjub, in []byte) (*SchnorrProof, error) {
+	if len(in) != SchnorrProofSize {
+		return nil, ErrInvalidProof
+	}
+	R, err := curve.Decompress(in[:32])
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	S, err := curve.ScalarFromBytes(in[32:])
+	if err != nil {
+		return nil, ErrInvalidProof
+	}
+	return &SchnorrProof{R, S}, nil
+}
@@ -0,0 +1,160 @@
+package zkp
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/gtank/jubjub"
+)
+
+func TestSchnorr(t *testing.T) {
+	curve := jubjub.Curve()
+	schnorr := NewSchnorr(curve, curve.SubgroupGenerator())
+
+	x, _ := curve.RandomScalar(nil)
+	P, proof, err := schnorr.Prove(NewTranscript("test"), x, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := curve.ScalarMult(x, curve.SubgroupGenerator()); !P.Equals(want) {
+		t.Fatal("Prove returned the wrong public point")
+	}
+	if !schnorr.Verify(NewTranscript("test"), P, proof) {
+		t.Fatal("Proof didn't verify")
+	}
+
+	if schnorr.Verify(NewTranscript("other"), P, proof) {
+		t.Error("Proof verified under a different transcript")
+	}
+	Q, _ := curve.RandomSubgroupPoint(nil)
+	if schnorr.Verify(NewTranscript("test"), Q, proof) {
+		t.Error("Proof verified for the wrong statement")
+	}
+	other := NewSchnorr(curve, Q)
+	if other.Verify(NewTranscript("test"), P, proof) {
+		t.Error("Proof verified for the wrong base")
+	}
+
+	for name, bad := range map[string]*SchnorrProof{
+		"nil proof": nil,
+		"nil R":     {nil, proof.S},
+		"nil s":     {proof.R, nil},
+	} {
+		if schnorr.Verify(NewTranscript("test"), P, bad) {
+			t.Errorf("Proof verified with %s", name)
+		}
+	}
+	if schnorr.Verify(NewTranscript("test"), nil, proof) {
+		t.Error("Proof verified for a nil statement")
+	}
+
+	enc, err := proof.MarshalBinary()
+	if err != nil || len(enc) != SchnorrProofSize {
+		t.Fatal("Proof encoding has the wrong length")
+	}
+	decoded, err := SchnorrProofFromBytes(curve, enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !schnorr.Verify(NewTranscript("test"), P, decoded) {
+		t.Error("Decoded proof didn't verify")
+	}
+
+	enc[40] ^= 1
+	if tampered, err := SchnorrProofFromBytes(curve, enc); err == nil && schnorr.Verify(NewTranscript("test"), P, tampered) {
+		t.Error("Tampered proof verified")
+	}
+	if _, err := SchnorrProofFromBytes(curve, enc[:63]); err != ErrInvalidProof {
+		t.Error("Decoded a short proof")
+	}
+	for i := 32; i < 64; i++ {
+		enc[i] = 0xff
+	}
+	if _, err := SchnorrProofFromBytes(curve, enc); err != ErrInvalidProof {
+		t.Error("Decoded a proof with s out of range")
+	}
+}
+
+func TestSchnorrNonce(t *testing.T) {
+	curve := jubjub.Curve()
+	schnorr := NewSchnorr(curve, curve.SubgroupGenerator())
+	fixed := bytes.Repeat([]byte{0x42}, 32)
+
+	x, _ := curve.RandomScalar(nil)
+	y, _ := curve.RandomScalar(nil)
+	_, px, _ := schnorr.Prove(NewTranscript("test"), x, bytes.NewReader(fixed))
+	_, py, _ := schnorr.Prove(NewTranscript("test"), y, bytes.NewReader(fixed))
+	_, pz, _ := schnorr.Prove(NewTranscript("other"), x, bytes.NewReader(fixed))
+	if px.R.Equals(py.R) || px.R.Equals(pz.R) {
+		t.Error("Nonce didn't depend on the witness and transcript")
+	}
+
+	if _, _, err := schnorr.Prove(NewTranscript("test"), x, bytes.NewReader(nil)); err == nil {
+		t.Error("Prove succeeded without randomness")
+	}
+}
+
+// smallOrderPoint returns (0, -1), which has order 2 and is encoded as p - 1
+// with a clear sign bit.
+func smallOrderPoint(t *testing.T, curve *jubjub.Jubjub) *jubjub.Point {
+	enc := new(big.Int).Sub(curve.Params().P, big.NewInt(1)).Bytes()
+	for i, j := 0, len(enc)-1; i < j; i, j = i+1, j-1 {
+		enc[i], enc[j] = enc[j], enc[i]
+	}
+	T, err := curve.Decompress(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return T
+}
+
+func TestSchnorrRejectsSmallOrder(t *testing.T) {
+	curve := jubjub.Curve()
+	G := curve.SubgroupGenerator()
+	schnorr := NewSchnorr(curve, G)
+
+	// P = [x] G + T has no discrete log to the base G, but a proof for x
+	// passes the verification equation whenever [c] T is the identity, which
+	// the prover can arrange by retrying the nonce until c is even.
+	x, _ := curve.RandomScalar(nil)
+	P, _ := curve.ScalarMult(x, G)
+	P.Add(P, smallOrderPoint(t, curve))
+
+	var proof *SchnorrProof
+	for proof == nil {
+		k, _ := curve.RandomScalar(nil)
+		R, _ := curve.ScalarMult(k, G)
+
+		tr := NewTranscript("test")
+		if err := schnorr.appendStatement(tr, P); err != nil {
+			t.Fatal(err)
+		}
+		c, err := schnorr.challenge(tr, R)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.ToBytes()[0]&1 == 1 {
+			continue
+		}
+
+		z := newScalar(curve)
+		z.Mul(c, x).Add(z, k)
+		proof = &SchnorrProof{R, z}
+	}
+
+	lhs, _ := curve.ScalarMult(proof.S, G)
+	c := func() *jubjub.Scalar {
+		tr := NewTranscript("test")
+		schnorr.appendStatement(tr, P)
+		c, _ := schnorr.challenge(tr, proof.R)
+		return c
+	}()
+	rhs, _ := curve.ScalarMult(c, P)
+	if !lhs.Equals(rhs.Add(rhs, proof.R)) {
+		t.Fatal("Forged proof doesn't satisfy the verification equation")
+	}
+	if schnorr.Verify(NewTranscript("test"), P, proof) {
+		t.Error("Verified a proof for a point outside the prime-order subgroup")
+	}
+}
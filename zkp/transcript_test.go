@@ -0,0 +1,47 @@
+package zkp
+
+import (
+	"testing"
+
+	"github.com/gtank/jubjub"
+)
+
+func TestTranscript(t *testing.T) {
+	curve := jubjub.Curve()
+
+	build := func(label string, msgs ...string) *Transcript {
+		tr := NewTranscript(label)
+		for i := 0; i+1 < len(msgs); i += 2 {
+			tr.AppendMessage(msgs[i], []byte(msgs[i+1]))
+		}
+		return tr
+	}
+
+	a := build("test", "m", "abc").ChallengeScalar(curve, "c")
+	if b := build("test", "m", "abc").ChallengeScalar(curve, "c"); !a.Equals(b) {
+		t.Error("Identical transcripts gave different challenges")
+	}
+	for i, tr := range []*Transcript{
+		build("other", "m", "abc"),
+		build("test", "m", "abd"),
+		build("test", "ma", "bc"),
+		build("test", "m", "ab", "", "c"),
+	} {
+		if tr.ChallengeScalar(curve, "c").Equals(a) {
+			t.Errorf("Distinct transcript %d gave the same challenge", i)
+		}
+	}
+	if build("test", "m", "abc").ChallengeScalar(curve, "d").Equals(a) {
+		t.Error("Challenge label wasn't absorbed")
+	}
+
+	tr := build("test", "m", "abc")
+	clone := tr.Clone()
+	c1 := tr.ChallengeScalar(curve, "c")
+	if c2 := tr.ChallengeScalar(curve, "c"); c1.Equals(c2) {
+		t.Error("Successive challenges repeated")
+	}
+	if c := clone.ChallengeScalar(curve, "c"); !c.Equals(c1) {
+		t.Error("Clone didn't preserve the transcript state")
+	}
+}